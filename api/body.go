@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// ReadData resolves a -d value: "@-" reads the body from stdin, "@path"
+// reads it from a file and anything else is used as is
+func ReadData(data string, stdin io.Reader) (string, error) {
+	if !strings.HasPrefix(data, "@") {
+		return data, nil
+	}
+	content, err := readSource(data[1:], stdin)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// readSource reads a file, or stdin when path is "-"
+func readSource(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, xurlErrors.NewIOError(fmt.Errorf("error reading stdin: %v", err))
+		}
+		return content, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xurlErrors.NewIOError(fmt.Errorf("error reading %s: %v", path, err))
+	}
+	return content, nil
+}
+
+// URLEncodeData builds a form body from --data-urlencode values, following
+// curl: "content" and "=content" encode content, "name=content" encodes only
+// the content, "@file" and "name@file" encode a file's content
+func URLEncodeData(items []string, stdin io.Reader) (string, error) {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		name, content := "", item
+		if idx := strings.IndexAny(item, "=@"); idx != -1 {
+			name = item[:idx]
+			content = item[idx+1:]
+			if item[idx] == '@' {
+				data, err := readSource(content, stdin)
+				if err != nil {
+					return "", err
+				}
+				content = string(data)
+			}
+		}
+
+		encoded := url.QueryEscape(content)
+		if name != "" {
+			encoded = name + "=" + encoded
+		}
+		parts = append(parts, encoded)
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// BuildJSONBody builds a JSON object from httpie-style items: "key=value"
+// sets a string and "key:=value" sets raw JSON (numbers, booleans, arrays,
+// objects). Dotted keys such as "reply.in_reply_to_tweet_id=123" create
+// nested objects.
+func BuildJSONBody(items []string) (string, error) {
+	body := make(map[string]any)
+	for _, item := range items {
+		key, value, err := parseJSONItem(item)
+		if err != nil {
+			return "", err
+		}
+		if err := setPath(body, strings.Split(key, "."), value); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", xurlErrors.NewJSONError(err)
+	}
+	return string(data), nil
+}
+
+// parseJSONItem splits a single key=value or key:=json item
+func parseJSONItem(item string) (string, any, error) {
+	idx := strings.Index(item, "=")
+	if idx <= 0 {
+		return "", nil, xurlErrors.NewJSONError(fmt.Errorf("invalid --json item %q: expected key=value or key:=json", item))
+	}
+
+	if item[idx-1] == ':' {
+		key := item[:idx-1]
+		var value any
+		if key == "" || json.Unmarshal([]byte(item[idx+1:]), &value) != nil {
+			return "", nil, xurlErrors.NewJSONError(fmt.Errorf("invalid --json item %q: value after := must be valid JSON", item))
+		}
+		return key, value, nil
+	}
+	return item[:idx], item[idx+1:], nil
+}
+
+// setPath sets value at a dotted key path, creating objects along the way
+func setPath(body map[string]any, path []string, value any) error {
+	for i, key := range path[:len(path)-1] {
+		next, ok := body[key]
+		if !ok {
+			child := make(map[string]any)
+			body[key] = child
+			body = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return xurlErrors.NewJSONError(fmt.Errorf("--json key %q is both a value and an object", strings.Join(path[:i+1], ".")))
+		}
+		body = child
+	}
+	body[path[len(path)-1]] = value
+	return nil
+}
+
+// AddQueryParams appends key=value parameters to an endpoint's query string,
+// escaping them as needed. A key may be given more than once, and any query
+// already on the endpoint is kept as written.
+func AddQueryParams(endpoint string, params []string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", xurlErrors.NewHTTPError(fmt.Errorf("invalid endpoint %q: %v", endpoint, err))
+	}
+
+	query := url.Values{}
+	for _, param := range params {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || key == "" {
+			return "", xurlErrors.NewHTTPError(fmt.Errorf("invalid --param %q: expected key=value", param))
+		}
+		query.Add(key, value)
+	}
+
+	encoded := strings.ReplaceAll(query.Encode(), "+", "%20")
+	if u.RawQuery != "" {
+		u.RawQuery += "&" + encoded
+	} else {
+		u.RawQuery = encoded
+	}
+	return u.String(), nil
+}
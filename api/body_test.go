@@ -0,0 +1,105 @@
+package api
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xdevplatform/xurl/config"
+)
+
+func TestReadData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"text":"from file"}`), 0600))
+
+	data, err := ReadData(`{"text":"inline"}`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"inline"}`, data)
+
+	data, err = ReadData("@"+path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"from file"}`, data)
+
+	data, err = ReadData("@-", strings.NewReader(`{"text":"from stdin"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"from stdin"}`, data)
+
+	_, err = ReadData("@"+filepath.Join(t.TempDir(), "missing.json"), nil)
+	assert.Error(t, err)
+}
+
+func TestURLEncodeData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello & goodbye"), 0600))
+
+	data, err := URLEncodeData([]string{"status=hello world", "a b", "text@" + path}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "status=hello+world&a+b&text=hello+%26+goodbye", data)
+}
+
+func TestBuildJSONBody(t *testing.T) {
+	body, err := BuildJSONBody([]string{
+		"text=Hello world",
+		"reply.in_reply_to_tweet_id=123",
+		"poll.options:=[\"yes\",\"no\"]",
+		"poll.duration_minutes:=60",
+		"nullcast:=true",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"text": "Hello world",
+		"reply": {"in_reply_to_tweet_id": "123"},
+		"poll": {"options": ["yes", "no"], "duration_minutes": 60},
+		"nullcast": true
+	}`, body)
+
+	_, err = BuildJSONBody([]string{"novalue"})
+	assert.Error(t, err)
+
+	_, err = BuildJSONBody([]string{"count:=not json"})
+	assert.Error(t, err)
+
+	_, err = BuildJSONBody([]string{"reply=1", "reply.id=2"})
+	assert.Error(t, err, "A key can't be both a value and an object")
+}
+
+func TestAddQueryParams(t *testing.T) {
+	endpoint, err := AddQueryParams("/2/tweets/search/recent?max_results=10", []string{"query=from:XDevelopers -is:retweet"})
+	require.NoError(t, err)
+	assert.Equal(t, "/2/tweets/search/recent?max_results=10&query=from%3AXDevelopers%20-is%3Aretweet", endpoint)
+
+	_, err = AddQueryParams("/2/users/me", []string{"=value"})
+	assert.Error(t, err)
+}
+
+func TestBuildRequestJSONFields(t *testing.T) {
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	client := NewApiClient(&config.Config{APIBaseURL: "https://api.x.com"}, authMock)
+
+	req, err := client.BuildRequest(RequestOptions{
+		Method:     "POST",
+		Endpoint:   "/2/tweets",
+		JSONFields: []string{"text=hi"},
+		Params:     []string{"dry=true"},
+		AuthType:   "app",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "dry=true", req.URL.RawQuery)
+	body, _ := io.ReadAll(req.Body)
+	assert.JSONEq(t, `{"text":"hi"}`, string(body))
+
+	_, err = client.BuildRequest(RequestOptions{
+		Method:     "POST",
+		Endpoint:   "/2/tweets",
+		Data:       `{"text":"hi"}`,
+		JSONFields: []string{"text=hi"},
+		AuthType:   "app",
+	})
+	assert.Error(t, err)
+}
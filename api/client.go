@@ -2,21 +2,22 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
-	"bufio"
-	"mime/multipart"
-	"os"
-	"path/filepath"
 	"github.com/xdevplatform/xurl/auth"
+	"github.com/xdevplatform/xurl/cassette"
 	"github.com/xdevplatform/xurl/config"
 	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/jq"
 	"github.com/xdevplatform/xurl/version"
 )
 
@@ -30,6 +31,37 @@ type RequestOptions struct {
 	Username string
 	Verbose  bool
 	Trace    bool
+	// JSONFields are httpie-style key=value / key:=json items that
+	// BuildRequest turns into a JSON body (see BuildJSONBody)
+	JSONFields []string
+	// Params are key=value pairs added to the query string with escaping
+	Params []string
+	// Pagination, when set, makes list requests follow next_token across pages
+	Pagination *PaginationOptions
+	// Output, when set, makes ExecuteRequest write the response curl-style
+	// instead of pretty-printing the JSON body
+	Output *OutputOptions
+	// Query, when set, filters the response (or each streamed line) with a jq expression
+	Query *jq.Query
+	// Idempotent marks a POST as safe to send again after a transient
+	// failure (e.g. a media APPEND segment)
+	Idempotent bool
+	// Form, when set, sends the request as multipart/form-data with these parts
+	Form []FormPart
+	// Curl, when set, prints the request as a curl command, and with
+	// Curl.DryRun doesn't send it
+	Curl *CurlOptions
+	// Stream, when set, controls stall detection and reconnection for
+	// streaming requests
+	Stream *StreamOptions
+	// OnResponse, when set, receives the response the request ended with,
+	// including error responses, with its status, headers and raw body
+	OnResponse func(resp *Response)
+
+	// keepStream leaves a response that turns out to be a stream open, in
+	// the *openStream error SendRequest returns, so it can be streamed
+	// without connecting again
+	keepStream bool
 }
 
 // MultipartOptions contains options specific to multipart requests
@@ -42,43 +74,147 @@ type MultipartOptions struct {
 	FileData   []byte
 }
 
-// Client is an interface for API clients
+// Client is an interface for API clients. The Context variants stop waiting,
+// retrying and reading the response once ctx is cancelled.
 type Client interface {
 	BuildRequest(requestOptions RequestOptions) (*http.Request, error)
 	BuildMultipartRequest(options MultipartOptions) (*http.Request, error)
 	SendRequest(options RequestOptions) (json.RawMessage, error)
+	SendRequestContext(ctx context.Context, options RequestOptions) (json.RawMessage, error)
 	StreamRequest(options RequestOptions) error
+	StreamRequestContext(ctx context.Context, options RequestOptions) error
 	SendMultipartRequest(options MultipartOptions) (json.RawMessage, error)
+	SendMultipartRequestContext(ctx context.Context, options MultipartOptions) (json.RawMessage, error)
 }
 
 // ApiClient handles API requests
 type ApiClient struct {
-	url    string
-	client *http.Client
-	auth   *auth.Auth
+	url         string
+	client      *http.Client
+	auth        *auth.Auth
+	ctx         context.Context
+	waitOnLimit bool
+	retry       RetryPolicy
+
+	mu            sync.Mutex
+	rateLimits    map[string]*RateLimit
+	lastRateLimit *RateLimit
 }
 
 // NewApiClient creates a new ApiClient
 func NewApiClient(config *config.Config, auth *auth.Auth) *ApiClient {
 	return &ApiClient{
-		url:    config.APIBaseURL,
-		client: &http.Client{Timeout: 30 * time.Second},
-		auth:   auth,
+		url:        config.APIBaseURL,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		auth:       auth,
+		rateLimits: make(map[string]*RateLimit),
+	}
+}
+
+// WithContext sets the context used by the methods that don't take one, so
+// that cancelling it aborts every request made through the client
+func (c *ApiClient) WithContext(ctx context.Context) *ApiClient {
+	c.ctx = ctx
+	return c
+}
+
+// baseContext returns the context set with WithContext, or a background context
+func (c *ApiClient) baseContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// WithHTTPClient sets the HTTP client used to send requests. Streams reuse
+// its transport without the overall timeout.
+func (c *ApiClient) WithHTTPClient(client *http.Client) *ApiClient {
+	if client != nil {
+		c.client = client
 	}
+	return c
+}
+
+// WithRecorder records every request and its response, scrubbed of
+// credentials, as cassettes in dir
+func (c *ApiClient) WithRecorder(dir string) *ApiClient {
+	client := *c.client
+	client.Transport = cassette.NewRecorder(dir, client.Transport)
+	c.client = &client
+	return c
+}
+
+// WithReplay answers every request from the cassettes in dir instead of
+// sending it
+func (c *ApiClient) WithReplay(dir string) *ApiClient {
+	client := *c.client
+	client.Transport = cassette.NewReplayer(dir)
+	c.client = &client
+	return c
+}
+
+// WithWaitOnLimit makes the client sleep until the window resets when it is
+// rate limited, and pace repeated requests to the remaining budget
+func (c *ApiClient) WithWaitOnLimit(wait bool) *ApiClient {
+	c.waitOnLimit = wait
+	return c
+}
+
+// WithRetryPolicy sets how requests are retried after network errors, 5xx
+// responses and 429s
+func (c *ApiClient) WithRetryPolicy(policy RetryPolicy) *ApiClient {
+	c.retry = policy
+	return c
+}
+
+// LastRateLimit returns the rate limit reported by the most recent response,
+// or nil if no response has carried x-rate-limit-* headers yet
+func (c *ApiClient) LastRateLimit() *RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastRateLimit
 }
 
 // BuildRequest builds an HTTP request
 func (c *ApiClient) BuildRequest(requestOptions RequestOptions) (*http.Request, error) {
+	if len(requestOptions.Form) > 0 {
+		if requestOptions.Data != "" || len(requestOptions.JSONFields) > 0 {
+			return nil, xurlErrors.NewHTTPError(errors.New("--form can't be combined with -d or --json"))
+		}
+		return c.BuildMultipartRequest(MultipartOptions{RequestOptions: requestOptions})
+	}
+
 	httpMethod := strings.ToUpper(requestOptions.Method)
 
+	endpoint := requestOptions.Endpoint
+	if len(requestOptions.Params) > 0 {
+		var err error
+		endpoint, err = AddQueryParams(endpoint, requestOptions.Params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := requestOptions.Data
+	if len(requestOptions.JSONFields) > 0 {
+		if data != "" {
+			return nil, xurlErrors.NewHTTPError(errors.New("--json items can't be combined with -d"))
+		}
+		var err error
+		data, err = BuildJSONBody(requestOptions.JSONFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var body io.Reader
 	contentType := ""
 
-	if requestOptions.Data != "" && (httpMethod == "POST" || httpMethod == "PUT" || httpMethod == "PATCH") {
-		body = bytes.NewBufferString(requestOptions.Data)
+	if data != "" && (httpMethod == "POST" || httpMethod == "PUT" || httpMethod == "PATCH") {
+		body = bytes.NewBufferString(data)
 
 		var js json.RawMessage
-		if json.Unmarshal([]byte(requestOptions.Data), &js) == nil {
+		if json.Unmarshal([]byte(data), &js) == nil {
 			contentType = "application/json"
 		} else {
 			contentType = "application/x-www-form-urlencoded"
@@ -87,7 +223,7 @@ func (c *ApiClient) BuildRequest(requestOptions RequestOptions) (*http.Request,
 
 	return c.buildBaseRequest(
 		requestOptions.Method,
-		requestOptions.Endpoint,
+		endpoint,
 		body,
 		contentType,
 		requestOptions.Headers,
@@ -97,177 +233,236 @@ func (c *ApiClient) BuildRequest(requestOptions RequestOptions) (*http.Request,
 	)
 }
 
-// BuildMultipartRequest builds an HTTP request with multipart form data
+// BuildMultipartRequest builds an HTTP request with multipart form data.
+// File parts are streamed from disk when the request is sent rather than
+// read into memory up front.
 func (c *ApiClient) BuildMultipartRequest(options MultipartOptions) (*http.Request, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Handle file from path
-	if options.FileField != "" && options.FilePath != "" {
-		file, err := os.Open(options.FilePath)
+	endpoint := options.Endpoint
+	if len(options.Params) > 0 {
+		var err error
+		endpoint, err = AddQueryParams(endpoint, options.Params)
 		if err != nil {
-			return nil, xurlErrors.NewIOError(fmt.Errorf("error opening file: %v", err))
-		}
-		defer file.Close()
-
-		part, err := writer.CreateFormFile(options.FileField, filepath.Base(options.FilePath))
-		if err != nil {
-			return nil, xurlErrors.NewIOError(fmt.Errorf("error creating form file: %v", err))
-		}
-
-		if _, err := io.Copy(part, file); err != nil {
-			return nil, xurlErrors.NewIOError(fmt.Errorf("error copying file content: %v", err))
-		}
-	} else if options.FileField != "" && len(options.FileData) > 0 { // Handle file from buffer
-		part, err := writer.CreateFormFile(options.FileField, options.FileName)
-		if err != nil {
-			return nil, xurlErrors.NewIOError(fmt.Errorf("error creating form file: %v", err))
-		}
-
-		if _, err := part.Write(options.FileData); err != nil {
-			return nil, xurlErrors.NewIOError(fmt.Errorf("error writing file data: %v", err))
+			return nil, err
 		}
 	}
 
-	for key, value := range options.FormFields {
-		if err := writer.WriteField(key, value); err != nil {
-			return nil, xurlErrors.NewIOError(fmt.Errorf("error writing form field: %v", err))
-		}
-	}
-
-	if err := writer.Close(); err != nil {
-		return nil, xurlErrors.NewIOError(fmt.Errorf("error closing multipart writer: %v", err))
+	body, contentType, length, err := newMultipartBody(multipartParts(options))
+	if err != nil {
+		return nil, err
 	}
 
 	// Use the common base request builder with the multipart content type
-	return c.buildBaseRequest(
+	req, err := c.buildBaseRequest(
 		options.Method,
-		options.Endpoint,
+		endpoint,
 		body,
-		writer.FormDataContentType(),
+		contentType,
 		options.Headers,
 		options.AuthType,
 		options.Username,
 		options.Trace,
 	)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.ContentLength = length
+	return req, nil
 }
 
 // SendRequest sends an HTTP request
 func (c *ApiClient) SendRequest(options RequestOptions) (json.RawMessage, error) {
-	req, err := c.BuildRequest(options)
-	if err != nil {
-		return nil, xurlErrors.NewHTTPError(err)
-	}
-
-	c.logRequest(req, options.Verbose)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, xurlErrors.NewHTTPError(err)
-	}
-	defer resp.Body.Close()
+	return c.SendRequestContext(c.baseContext(), options)
+}
 
-	return c.processResponse(resp, options.Verbose)
+// SendRequestContext sends an HTTP request that is cancelled along with ctx
+func (c *ApiClient) SendRequestContext(ctx context.Context, options RequestOptions) (json.RawMessage, error) {
+	return c.send(ctx, func() (*http.Request, error) {
+		req, err := c.BuildRequest(options)
+		if err != nil {
+			return nil, xurlErrors.NewHTTPError(err)
+		}
+		return req, nil
+	}, options)
 }
 
 // SendMultipartRequest sends an HTTP request with multipart form data
 func (c *ApiClient) SendMultipartRequest(options MultipartOptions) (json.RawMessage, error) {
-	req, err := c.BuildMultipartRequest(options)
-	if err != nil {
-		return nil, err
-	}
-
-	c.logRequest(req, options.Verbose)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, xurlErrors.NewHTTPError(err)
-	}
-	defer resp.Body.Close()
+	return c.SendMultipartRequestContext(c.baseContext(), options)
+}
 
-	return c.processResponse(resp, options.Verbose)
+// SendMultipartRequestContext sends a multipart request that is cancelled along with ctx
+func (c *ApiClient) SendMultipartRequestContext(ctx context.Context, options MultipartOptions) (json.RawMessage, error) {
+	// send prints --curl commands from the parts, as the request carries
+	// only the encoded body
+	requestOptions := options.RequestOptions
+	requestOptions.Form = multipartParts(options)
+	return c.send(ctx, func() (*http.Request, error) {
+		return c.BuildMultipartRequest(options)
+	}, requestOptions)
 }
 
-// StreamRequest sends an HTTP request and streams the response
-func (c *ApiClient) StreamRequest(options RequestOptions) error {
-	req, err := c.BuildRequest(options)
-	if err != nil {
-		return xurlErrors.NewHTTPError(err)
-	}
+// send builds and sends a request and processes its response. The request is
+// rebuilt for every attempt so that OAuth1 signatures and bodies are fresh
+// when a rate limited or failed request is sent again.
+func (c *ApiClient) send(ctx context.Context, build func() (*http.Request, error), options RequestOptions) (json.RawMessage, error) {
+	start := time.Now()
+	retries, rateLimitWaits := 0, 0
+	for {
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
 
-	if options.Verbose {
-		fmt.Printf("\033[1;34m> %s\033[0m %s\n", req.Method, req.URL)
-		for key, values := range req.Header {
-			for _, value := range values {
-				fmt.Printf("\033[1;36m> %s\033[0m: %s\n", key, value)
+		// Only the first attempt is printed
+		if options.Curl != nil && retries == 0 && rateLimitWaits == 0 {
+			if err := printCurl(req, options); err != nil {
+				return nil, xurlErrors.NewIOError(err)
+			}
+			if options.Curl.DryRun {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+				return nil, nil
 			}
 		}
-		fmt.Println()
-	}
-
-	client := &http.Client{
-		Timeout: 0,
-	}
 
-	fmt.Printf("\033[1;32mConnecting to streaming endpoint: %s\033[0m\n", options.Endpoint)
+		if err := c.paceRequest(ctx, req); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, xurlErrors.NewHTTPError(err)
+		}
+		c.logRequest(req, options.Verbose)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return xurlErrors.NewHTTPError(err)
-	}
-	defer resp.Body.Close()
+		client, lift := c.client, func() context.CancelFunc { return func() {} }
+		if options.keepStream {
+			client, req, lift = c.streamClient(req)
+		}
 
-	if options.Verbose {
-		fmt.Printf("\033[1;31m< %s\033[0m\n", resp.Status)
-		for key, values := range resp.Header {
-			for _, value := range values {
-				fmt.Printf("\033[1;32m< %s\033[0m: %s\n", key, value)
+		sent := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			lift()()
+			delay := c.retry.backoff(retries + 1)
+			if ctx.Err() == nil && c.shouldRetry(req, options, start, retries, delay) {
+				retries++
+				if err := c.waitToRetry(ctx, err.Error(), retries, delay); err != nil {
+					return nil, xurlErrors.NewHTTPError(err)
+				}
+				continue
 			}
+			return nil, xurlErrors.NewHTTPError(err)
 		}
-		fmt.Println()
-	}
 
-	if resp.StatusCode >= 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return xurlErrors.NewIOError(err)
+		rateLimit := c.recordRateLimit(req, resp)
+		if resp.StatusCode == http.StatusTooManyRequests && c.waitOnLimit && rateLimitWaits < maxRateLimitWaits {
+			resp.Body.Close()
+			lift()()
+			rateLimitWaits++
+			wait := rateLimitWait(rateLimit)
+			fmt.Fprintf(os.Stderr, "\033[33mRate limited, waiting %s for the window to reset...\033[0m\n", wait.Round(time.Second))
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, xurlErrors.NewHTTPError(err)
+			}
+			continue
 		}
 
-		var js json.RawMessage
-		if err := json.Unmarshal(body, &js); err != nil {
-			return xurlErrors.NewJSONError(err)
+		if isRetryableStatus(resp.StatusCode) {
+			delay := max(c.retry.backoff(retries+1), retryAfter(resp.Header))
+			if c.shouldRetry(req, options, start, retries, delay) {
+				resp.Body.Close()
+				lift()()
+				retries++
+				if err := c.waitToRetry(ctx, resp.Status, retries, delay); err != nil {
+					return nil, xurlErrors.NewHTTPError(err)
+				}
+				continue
+			}
 		}
 
-		return xurlErrors.NewAPIError(js)
+		js, err := c.processResponse(resp, options, sent)
+		var open *openStream
+		if options.keepStream && errors.As(err, &open) {
+			open.cancel = lift()
+		} else {
+			resp.Body.Close()
+			lift()()
+		}
+		return js, err
 	}
+}
 
-	scanner := bufio.NewScanner(resp.Body)
+// streamClient returns a client without a timeout, and req with c.client's
+// timeout as a deadline instead, for a request whose response may be kept
+// open as a stream. lift removes the deadline once the response is a
+// stream and returns what ends its context.
+func (c *ApiClient) streamClient(req *http.Request) (*http.Client, *http.Request, func() context.CancelFunc) {
+	client := *c.client
+	client.Timeout = 0
+	ctx, cancel := context.WithCancel(req.Context())
+	deadline := func() {}
+	if c.client.Timeout > 0 {
+		timer := time.AfterFunc(c.client.Timeout, cancel)
+		deadline = func() { timer.Stop() }
+	}
+	lift := func() context.CancelFunc {
+		deadline()
+		return cancel
+	}
+	return &client, req.WithContext(ctx), lift
+}
 
-	const maxScanTokenSize = 1024 * 1024
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
+// shouldRetry reports whether the retry policy allows another attempt that
+// would start after delay
+func (c *ApiClient) shouldRetry(req *http.Request, options RequestOptions, start time.Time, retries int, delay time.Duration) bool {
+	if !c.retry.canRetry(req.Method, options.Idempotent) || retries >= c.retry.MaxRetries {
+		return false
+	}
+	return c.retry.MaxTime <= 0 || time.Since(start)+delay < c.retry.MaxTime
+}
 
-	fmt.Println("\033[1;32m--- Streaming response started ---\033[0m")
-	fmt.Println("\033[1;32m--- Press Ctrl+C to stop ---\033[0m")
+// waitToRetry reports a failed attempt and sleeps before the next one
+func (c *ApiClient) waitToRetry(ctx context.Context, reason string, retry int, delay time.Duration) error {
+	fmt.Fprintf(os.Stderr, "\033[33mRequest failed (%s), retrying in %s (%d/%d)...\033[0m\n", reason, delay.Round(time.Millisecond), retry, c.retry.MaxRetries)
+	return sleepContext(ctx, delay)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// recordRateLimit remembers the rate limit reported by a response
+func (c *ApiClient) recordRateLimit(req *http.Request, resp *http.Response) *RateLimit {
+	rateLimit := ParseRateLimit(resp.Header)
+	if rateLimit == nil {
+		return nil
+	}
 
-		if line == "" {
-			continue
-		}
-		// We can't pretty-print streaming responses
-		fmt.Println(line)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rateLimits == nil {
+		c.rateLimits = make(map[string]*RateLimit)
 	}
+	c.rateLimits[rateLimitKey(req)] = rateLimit
+	c.lastRateLimit = rateLimit
+	return rateLimit
+}
 
-	if err := scanner.Err(); err != nil {
-		if err == bufio.ErrTooLong {
-			return xurlErrors.NewIOError(fmt.Errorf("line too long: increase buffer size"))
-		}
-		return xurlErrors.NewIOError(err)
+// paceRequest delays a request when waiting on limits is enabled and an
+// earlier response showed the endpoint's budget running out
+func (c *ApiClient) paceRequest(ctx context.Context, req *http.Request) error {
+	if !c.waitOnLimit {
+		return nil
 	}
 
-	fmt.Println("\033[1;32m--- End of stream ---\033[0m")
+	c.mu.Lock()
+	rateLimit := c.rateLimits[rateLimitKey(req)]
+	c.mu.Unlock()
+
+	if delay := PaceDelay(rateLimit); delay > 0 {
+		if rateLimit.Remaining <= 0 {
+			fmt.Fprintf(os.Stderr, "\033[33mRate limit exhausted, waiting %s for the window to reset...\033[0m\n", delay.Round(time.Second))
+		}
+		return sleepContext(ctx, delay)
+	}
 	return nil
 }
 
@@ -335,7 +530,7 @@ func (c *ApiClient) getAuthHeader(method, url string, authType string, username
 	if authType != "" {
 		switch strings.ToLower(authType) {
 		case "oauth1":
-			return c.auth.GetOAuth1Header(method, url, nil)
+			return c.auth.GetOAuth1HeaderForUser(method, url, username, nil)
 		case "oauth2":
 			return c.auth.GetOAuth2Header(username)
 		case "app":
@@ -354,13 +549,11 @@ func (c *ApiClient) getAuthHeader(method, url string, authType string, username
 		}
 	}
 
-	// If no OAuth2 token is available, try to use the first OAuth1 token
-	token = c.auth.TokenStore.GetOAuth1Tokens()
-	if token != nil {
-		authHeader, err := c.auth.GetOAuth1Header(method, url, nil)
-		if err == nil {
-			return authHeader, nil
-		}
+	// If no OAuth2 token is available, try to use the default OAuth1
+	// account. The username is an OAuth2 one here, so it isn't passed on.
+	authHeader, err := c.auth.GetOAuth1Header(method, url, nil)
+	if err == nil {
+		return authHeader, nil
 	}
 
 	// If no OAuth1 token is available, try to use the bearer token
@@ -387,19 +580,38 @@ func (c *ApiClient) logRequest(req *http.Request, verbose bool) {
 }
 
 // processResponse handles common response processing logic
-func (c *ApiClient) processResponse(resp *http.Response, verbose bool) (json.RawMessage, error) {
-	responseBody, err := io.ReadAll(resp.Body)
+func (c *ApiClient) processResponse(resp *http.Response, options RequestOptions, sent time.Time) (json.RawMessage, error) {
+	stream, body := isStreamResponse(resp)
+	if stream {
+		return nil, &openStream{resp: resp, body: body}
+	}
+	responseBody, err := io.ReadAll(body)
 	if err != nil {
 		return nil, xurlErrors.NewIOError(err)
 	}
 
-	if verbose {
+	if options.OnResponse != nil {
+		options.OnResponse(&Response{
+			Proto:      resp.Proto,
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       responseBody,
+			URL:        resp.Request.URL.String(),
+			Duration:   time.Since(sent),
+		})
+	}
+
+	rateLimit := ParseRateLimit(resp.Header)
+
+	if options.Verbose {
 		fmt.Printf("\033[1;31m< %s\033[0m\n", resp.Status)
 		for key, values := range resp.Header {
 			for _, value := range values {
 				fmt.Printf("\033[1;32m< %s\033[0m: %s\n", key, value)
 			}
 		}
+		logRateLimit(rateLimit)
 		fmt.Println()
 	}
 
@@ -407,7 +619,7 @@ func (c *ApiClient) processResponse(resp *http.Response, verbose bool) (json.Raw
 	if len(responseBody) > 0 {
 		if err := json.Unmarshal(responseBody, &js); err != nil {
 			if resp.StatusCode >= 400 {
-				return nil, xurlErrors.NewHTTPError(fmt.Errorf("HTTP error: %s", resp.Status))
+				return nil, statusError(resp)
 			}
 			js = json.RawMessage("{}")
 		}
@@ -416,8 +628,26 @@ func (c *ApiClient) processResponse(resp *http.Response, verbose bool) (json.Raw
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, xurlErrors.NewAPIError(js)
+		return nil, xurlErrors.NewResponseError(resp.StatusCode, js, rateLimit)
+	}
+	// Looking up a single missing resource succeeds with nothing but errors
+	if problem := xurlErrors.DecodeProblem(js); problem != nil && problem.Failed() {
+		return nil, xurlErrors.NewResponseError(resp.StatusCode, js, rateLimit)
 	}
 
 	return js, nil
 }
+
+// statusError is the error for a failed response without a JSON body
+func statusError(resp *http.Response) error {
+	e := xurlErrors.NewHTTPError(fmt.Errorf("HTTP error: %s", resp.Status))
+	e.StatusCode = resp.StatusCode
+	return e
+}
+
+// logRateLimit prints a summary of the rate limit headers in verbose mode
+func logRateLimit(rateLimit *RateLimit) {
+	if rateLimit != nil {
+		fmt.Printf("\033[1;33m< rate limit\033[0m: %s\n", rateLimit)
+	}
+}
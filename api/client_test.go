@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
@@ -200,6 +202,13 @@ func TestSendRequest(t *testing.T) {
 			return
 		}
 
+		if r.URL.Path == "/2/tweets/404" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"errors":[{"title":"Not Found Error","type":"https://api.x.com/2/problems/resource-not-found","detail":"Could not find tweet with id: [404]."}]}`))
+			return
+		}
+
 		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer server.Close()
@@ -279,6 +288,20 @@ func TestSendRequest(t *testing.T) {
 		assert.Error(t, err, "Expected an error")
 		assert.Nil(t, resp, "Response should be nil")
 		assert.True(t, xurlErrors.IsAPIError(err), "Expected API error")
+		assert.Equal(t, xurlErrors.ExitInvalidRequest, xurlErrors.ExitCode(err))
+	})
+
+	t.Run("Missing resource", func(t *testing.T) {
+		options := RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/404",
+		}
+
+		resp, err := client.SendRequest(options)
+
+		assert.Error(t, err, "A 200 with only errors is a failure")
+		assert.Nil(t, resp, "Response should be nil")
+		assert.Equal(t, xurlErrors.ExitNotFound, xurlErrors.ExitCode(err))
 	})
 }
 
@@ -355,4 +378,39 @@ func TestStreamRequest(t *testing.T) {
 		assert.Error(t, err, "Expected an error")
 		assert.True(t, xurlErrors.IsAPIError(err), "Expected API error")
 	})
+
+	t.Run("Cancelled stream ends cleanly", func(t *testing.T) {
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"id":"1"}}` + "\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer stream.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		streamClient := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		err := streamClient.StreamRequestContext(ctx, RequestOptions{Method: "GET", Endpoint: "/2/tweets/search/stream"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestSendRequestContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).
+		WithRetryPolicy(RetryPolicy{MaxRetries: 5, BaseDelay: time.Hour})
+	_, err := client.SendRequestContext(ctx, RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+	assert.ErrorIs(t, err, context.Canceled)
 }
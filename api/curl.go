@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CurlOptions controls printing requests as curl commands
+type CurlOptions struct {
+	// DryRun prints the command to stdout instead of sending the request.
+	// Otherwise the command goes to stderr and the request is sent.
+	DryRun bool
+	// ShowAuth prints the real Authorization header instead of redacting it
+	ShowAuth bool
+}
+
+// curlHeaderSkip lists headers curl sets by itself
+var curlHeaderSkip = map[string]bool{
+	"Content-Length": true,
+}
+
+// CurlCommand renders req as a ready-to-run curl command line. Multipart
+// bodies are written as -F parts from form; other bodies are read without
+// consuming req.Body.
+func CurlCommand(req *http.Request, form []FormPart, showAuth bool) (string, error) {
+	lines := []string{fmt.Sprintf("curl -X %s %s", req.Method, shellQuote(curlURL(req.URL)))}
+
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if curlHeaderSkip[key] || (key == "Content-Type" && len(form) > 0) {
+			continue
+		}
+		for _, value := range req.Header[key] {
+			if key == "Authorization" && !showAuth {
+				value = redactAuthorization(value)
+			}
+			lines = append(lines, "-H "+shellQuote(key+": "+value))
+		}
+	}
+
+	if len(form) > 0 {
+		for _, part := range form {
+			lines = append(lines, "-F "+shellQuote(curlFormPart(part)))
+		}
+	} else {
+		body, err := peekBody(req)
+		if err != nil {
+			return "", err
+		}
+		if len(body) > 0 {
+			lines = append(lines, "--data-raw "+shellQuote(string(body)))
+		}
+	}
+
+	return strings.Join(lines, " \\\n  "), nil
+}
+
+// curlURL renders u with dry-run placeholders such as {my_user_id} left
+// readable in the path
+func curlURL(u *url.URL) string {
+	path := u.EscapedPath()
+	readable := strings.NewReplacer("%7B", "{", "%7D", "}").Replace(path)
+	return strings.Replace(u.String(), path, readable, 1)
+}
+
+// printCurl writes the curl command for req to stdout for a dry run, or
+// to stderr ahead of sending it
+func printCurl(req *http.Request, options RequestOptions) error {
+	command, err := CurlCommand(req, options.Form, options.Curl.ShowAuth)
+	if err != nil {
+		return err
+	}
+	w := os.Stderr
+	if options.Curl.DryRun {
+		w = os.Stdout
+	}
+	fmt.Fprintln(w, command)
+	return nil
+}
+
+// curlFormPart renders a part in curl's -F syntax
+func curlFormPart(part FormPart) string {
+	if !part.IsFile() {
+		return part.Name + "=" + part.Value
+	}
+	path := part.FilePath
+	if path == "" {
+		path = part.FileName
+	}
+	value := part.Name + "=@" + path
+	if part.ContentType != "" {
+		value += ";type=" + part.ContentType
+	}
+	if part.FileName != "" {
+		value += ";filename=" + part.FileName
+	}
+	return value
+}
+
+// peekBody returns a copy of the request body, leaving it readable
+func peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// redactAuthorization keeps the scheme of an Authorization header and hides
+// its credentials
+func redactAuthorization(value string) string {
+	scheme, _, found := strings.Cut(value, " ")
+	if !found {
+		return "REDACTED"
+	}
+	return scheme + " REDACTED"
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@,+%", r))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
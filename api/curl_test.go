@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurlCommand(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://api.x.com/2/tweets", bytes.NewBufferString(`{"text":"it's"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Content-Type", "application/json")
+
+	command, err := CurlCommand(req, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "curl -X POST https://api.x.com/2/tweets \\\n"+
+		"  -H 'Authorization: Bearer REDACTED' \\\n"+
+		"  -H 'Content-Type: application/json' \\\n"+
+		`  --data-raw '{"text":"it'\''s"}'`, command)
+
+	command, err = CurlCommand(req, nil, true)
+	require.NoError(t, err)
+	assert.Contains(t, command, "-H 'Authorization: Bearer secret-token'")
+
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, `{"text":"it's"}`, string(body), "The body is left for sending")
+
+	req, err = http.NewRequest("GET", "https://api.x.com/2/users/{my_user_id}/bookmarks?q=%7Bx%7D", nil)
+	require.NoError(t, err)
+	command, err = CurlCommand(req, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "curl -X GET 'https://api.x.com/2/users/{my_user_id}/bookmarks?q=%7Bx%7D'", command, "Placeholders stay readable in the path")
+}
+
+func TestCurlCommandForm(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://api.x.com/2/media/upload", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=abc")
+
+	command, err := CurlCommand(req, []FormPart{
+		{Name: "media", FilePath: "/tmp/my cat.png", ContentType: "image/png"},
+		{Name: "media_category", Value: "tweet_image"},
+	}, false)
+	require.NoError(t, err)
+	assert.NotContains(t, command, "boundary")
+	assert.Contains(t, command, "-F 'media=@/tmp/my cat.png;type=image/png'")
+	assert.Contains(t, command, "-F media_category=tweet_image")
+}
+
+func TestDryRunDoesNotSend(t *testing.T) {
+	sent := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = true
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	response, err := client.SendRequest(RequestOptions{
+		Method:   "DELETE",
+		Endpoint: "/2/tweets/123",
+		Curl:     &CurlOptions{DryRun: true},
+	})
+	w.Close()
+	os.Stdout = stdout
+	require.NoError(t, err)
+
+	out, _ := io.ReadAll(r)
+	assert.Nil(t, response)
+	assert.False(t, sent)
+	assert.Contains(t, string(out), "curl -X DELETE "+server.URL+"/2/tweets/123")
+}
+
+func TestCurlPrintedOnceWithRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"42"}}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server).WithRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	stderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	_, err := client.SendRequest(RequestOptions{
+		Method:   "GET",
+		Endpoint: "/2/users/me",
+		Curl:     &CurlOptions{},
+	})
+	w.Close()
+	os.Stderr = stderr
+	require.NoError(t, err)
+
+	out, _ := io.ReadAll(r)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 1, strings.Count(string(out), "curl -X GET"), "Retries aren't printed again: %s", out)
+}
@@ -1,22 +1,37 @@
 package api
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
 	"strings"
 )
 
-// StreamingEndpoints is a map of endpoint prefixes that should be streamed
-var StreamingEndpoints = map[string]bool{
-	"/2/tweets/search/stream":           true,
-	"/2/tweets/sample/stream":           true,
-	"/2/tweets/sample10/stream":         true,
-	"/2/tweets/firehose/stream":         true,
-	"/2/tweets/firehose/stream/lang/en": true,
-	"/2/tweets/firehose/stream/lang/ja": true,
-	"/2/tweets/firehose/stream/lang/ko": true,
-	"/2/tweets/firehose/stream/lang/pt": true,
+// ErrStreamingResponse is returned by SendRequest when the response turns
+// out to be a stream, which has to be read with StreamRequest instead
+var ErrStreamingResponse = errors.New("the response is a stream")
+
+// openStream is a response that turned out to be a stream, with body reading
+// it from the start. As an error it is ErrStreamingResponse.
+type openStream struct {
+	resp *http.Response
+	body io.Reader
+	// cancel ends the context the response was received with
+	cancel func()
 }
 
-// IsStreamingEndpoint checks if an endpoint should be streamed
+func (o *openStream) Error() string { return ErrStreamingResponse.Error() }
+func (o *openStream) Unwrap() error { return ErrStreamingResponse }
+
+// IsStreamingEndpoint checks if an endpoint should be streamed. X's
+// streaming endpoints all have a "stream" path segment, e.g.
+// /2/tweets/search/stream, /2/tweets/firehose/stream/lang/en or
+// /2/users/compliance/stream, while the rules below a stream are ordinary
+// endpoints. Streams that don't follow the pattern are detected from their
+// response, see isStreamResponse.
 func IsStreamingEndpoint(endpoint string) bool {
 	path := endpoint
 	if strings.HasPrefix(strings.ToLower(endpoint), "http") {
@@ -31,7 +46,31 @@ func IsStreamingEndpoint(endpoint string) bool {
 		path = path[:queryIndex]
 	}
 
-	normalizedEndpoint := strings.TrimSuffix(path, "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "stream" {
+			continue
+		}
+		return i+1 == len(segments) || segments[i+1] != "rules"
+	}
+	return false
+}
+
+// isStreamResponse reports whether a response is a stream rather than a
+// single JSON document: a successful JSON response of unknown length (sent
+// chunked) whose first line ends with the CRLF that X puts after every
+// streamed line and heartbeat. It reads up to the end of the first line and
+// returns a reader for the whole body.
+func isStreamResponse(resp *http.Response) (bool, io.Reader) {
+	if resp.StatusCode != http.StatusOK || resp.ContentLength >= 0 {
+		return false, resp.Body
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return false, resp.Body
+	}
 
-	return StreamingEndpoints[normalizedEndpoint]
+	reader := bufio.NewReader(resp.Body)
+	first, err := reader.ReadBytes('\n')
+	body := io.MultiReader(bytes.NewReader(first), reader)
+	return err == nil && bytes.HasSuffix(first, []byte("\r\n")), body
 }
@@ -1,9 +1,20 @@
 package api
 
 import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
 )
 
 func TestIsStreamingEndpoint(t *testing.T) {
@@ -20,6 +31,10 @@ func TestIsStreamingEndpoint(t *testing.T) {
 		{"/2/tweets/firehose/stream/lang/ja", true},
 		{"/2/tweets/firehose/stream/lang/ko", true},
 		{"/2/tweets/firehose/stream/lang/pt", true},
+		{"/2/tweets/compliance/stream", true},
+		{"/2/users/compliance/stream", true},
+		{"/2/likes/firehose/stream", true},
+		{"/2/likes/sample10/stream", true},
 
 		// Test with trailing slash
 		{"/2/tweets/search/stream/", true},
@@ -37,6 +52,10 @@ func TestIsStreamingEndpoint(t *testing.T) {
 		{"/2/users/me", false},
 		{"https://api.x.com/2/users/me", false},
 		{"/not/a/streaming/endpoint", false},
+		{"/2/tweets/search/stream/rules", false},
+		{"/2/tweets/search/stream/rules/counts", false},
+		{"/2/tweets/search/stream/rules?dry_run=true", false},
+		{"/2/tweets/search/streaming", false},
 		{"", false},
 	}
 
@@ -47,3 +66,103 @@ func TestIsStreamingEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamResponseDetection(t *testing.T) {
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch r.URL.Path {
+		case "/2/labs/feed":
+			connections.Add(1)
+			w.Write([]byte("{\"data\":{\"id\":\"1\"}}\r\n"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("\r\n{\"data\":{\"id\":\"2\"}}\r\n"))
+		case "/2/labs/idle":
+			connections.Add(1)
+			w.Write([]byte("{\"data\":{\"id\":\"1\"}}\r\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/2/labs/slow":
+			for i := range 6 {
+				time.Sleep(100 * time.Millisecond)
+				w.Write([]byte(fmt.Sprintf("{\"id\":\"%d\"}\r\n", i)))
+				w.(http.Flusher).Flush()
+			}
+		case "/2/labs/chunked":
+			// Chunked like a stream, but a single document
+			w.Write([]byte("{\"data\":"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("{\"id\":\"3\"}}\n"))
+		default:
+			w.Write([]byte(`{"data":{"id":"4"}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+
+	t.Run("Streams are detected", func(t *testing.T) {
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/labs/feed"})
+		assert.ErrorIs(t, err, ErrStreamingResponse)
+
+		var out bytes.Buffer
+		connections.Store(0)
+		err = HandleRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/labs/feed",
+			Stream:   &StreamOptions{Output: &out},
+		}, false, "", client)
+		require.NoError(t, err)
+		assert.Equal(t, "{\"data\":{\"id\":\"1\"}}\n{\"data\":{\"id\":\"2\"}}\n", out.String(), "Streams switch to line by line output")
+		assert.Equal(t, int32(1), connections.Load(), "The open response is streamed without connecting again")
+	})
+
+	t.Run("Detected streams stall and stop like others", func(t *testing.T) {
+		var out bytes.Buffer
+		connections.Store(0)
+		err := HandleRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/labs/idle",
+			Stream:   &StreamOptions{StallTimeout: 100 * time.Millisecond, Output: &out},
+		}, false, "", client)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no data or heartbeat for 100ms")
+		assert.Equal(t, "{\"data\":{\"id\":\"1\"}}\n", out.String())
+
+		err = HandleRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/labs/idle",
+			Stream:   &StreamOptions{Duration: 100 * time.Millisecond, Output: &out},
+		}, false, "", client)
+		require.NoError(t, err, "Reaching the duration ends the stream cleanly")
+		assert.Equal(t, int32(2), connections.Load())
+	})
+
+	t.Run("Detected streams outlive the client timeout", func(t *testing.T) {
+		client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).
+			WithHTTPClient(&http.Client{Timeout: 300 * time.Millisecond})
+
+		var out bytes.Buffer
+		err := HandleRequest(RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/labs/slow",
+			Stream:   &StreamOptions{Output: &out},
+		}, false, "", client)
+		require.NoError(t, err)
+		assert.Equal(t, 6, strings.Count(out.String(), "\n"), "The stream is read to its end: %q", out.String())
+	})
+
+	t.Run("Documents are not streams", func(t *testing.T) {
+		for path, want := range map[string]string{
+			"/2/labs/chunked": `{"data":{"id":"3"}}`,
+			"/2/labs/other":   `{"data":{"id":"4"}}`,
+		} {
+			resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: path})
+			require.NoError(t, err)
+			assert.JSONEq(t, want, string(resp))
+		}
+	})
+}
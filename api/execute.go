@@ -2,23 +2,69 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 	"github.com/xdevplatform/xurl/utils"
 )
 
 // ExecuteRequest handles the execution of a regular API request
 func ExecuteRequest(options RequestOptions, client Client) error {
+	if options.Output != nil {
+		return executeWithOutput(options, client)
+	}
 
-	response, clientErr := client.SendRequest(options)
+	var response json.RawMessage
+	var clientErr error
+	if options.Pagination != nil {
+		response, clientErr = SendPaginatedRequest(client, options)
+	} else {
+		response, clientErr = client.SendRequest(options)
+	}
 	if clientErr != nil {
 		return handleRequestError(clientErr)
 	}
 
+	// Pages were already handed to Pagination.OnPage
+	if response == nil {
+		return nil
+	}
+	PrintPartialErrors(response)
+
+	if options.Query != nil {
+		return options.Query.Apply(response, os.Stdout)
+	}
 	return utils.FormatAndPrintResponse(response)
 }
 
-// ExecuteStreamRequest handles the execution of a streaming API request
+// ExecuteStreamRequest handles the execution of a streaming API request.
+// With options.Output.File set, the stream is appended to that file, which is
+// rotated according to options.Stream.
 func ExecuteStreamRequest(options RequestOptions, client Client) error {
+	if options.Output != nil && options.Output.File != "" {
+		var stream StreamOptions
+		if options.Stream != nil {
+			stream = *options.Stream
+		}
+		file, err := OpenRotatingFile(options.Output.File, stream.RotateSize, stream.RotateEvery, stream.Gzip)
+		if err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+		stream.Output = file
+		options.Stream = &stream
+
+		clientErr := client.StreamRequest(options)
+		closeErr := file.Close()
+		if clientErr != nil {
+			return handleRequestError(clientErr)
+		}
+		if closeErr != nil {
+			return xurlErrors.NewIOError(closeErr)
+		}
+		return nil
+	}
 
 	clientErr := client.StreamRequest(options)
 	if clientErr != nil {
@@ -28,12 +74,104 @@ func ExecuteStreamRequest(options RequestOptions, client Client) error {
 	return nil
 }
 
-// handleRequestError processes API client errors in a consistent way
+// executeWithOutput sends a request and writes the response it ended with
+// according to options.Output, including error responses
+func executeWithOutput(options RequestOptions, client Client) error {
+	var last *Response
+	options.OnResponse = func(resp *Response) {
+		last = resp
+	}
+
+	var response json.RawMessage
+	var clientErr error
+	if options.Pagination != nil {
+		response, clientErr = SendPaginatedRequest(client, options)
+	} else {
+		response, clientErr = client.SendRequest(options)
+	}
+
+	// No response was received at all, e.g. a network error
+	if last == nil {
+		if clientErr != nil {
+			return handleRequestError(clientErr)
+		}
+		return nil
+	}
+
+	// A paginated body is the merged pages, with the last page's headers
+	if options.Pagination != nil && clientErr == nil {
+		merged := *last
+		merged.Body = response
+		last = &merged
+	}
+
+	if options.Query != nil && clientErr == nil && last.Body != nil {
+		filtered, err := options.Query.Filter(last.Body)
+		if err != nil {
+			return err
+		}
+		result := *last
+		result.Body = filtered
+		last = &result
+	}
+
+	if err := WriteResponse(os.Stdout, last, *options.Output); err != nil {
+		return err
+	}
+	if clientErr != nil {
+		PrintErrorHint(clientErr)
+		return xurlErrors.Reported(clientErr)
+	}
+	if options.Output.File == "" {
+		PrintPartialErrors(last.Body)
+	}
+	return nil
+}
+
+// handleRequestError prints an API error response and what to do about it.
+// Other errors are returned as they are for the caller to print.
 func handleRequestError(clientErr error) error {
 	var rawJSON json.RawMessage
-	json.Unmarshal([]byte(clientErr.Error()), &rawJSON)
+	if json.Unmarshal([]byte(clientErr.Error()), &rawJSON) != nil {
+		return clientErr
+	}
 	utils.FormatAndPrintResponse(rawJSON)
-	return fmt.Errorf("request failed")
+
+	PrintErrorHint(clientErr)
+	return xurlErrors.Reported(clientErr)
+}
+
+// PrintErrorHint prints a summary of an X API error and advice on fixing
+// it to stderr
+func PrintErrorHint(err error) {
+	var e *xurlErrors.Error
+	if errors.As(err, &e) && e.Problem != nil {
+		problems := e.Problem.Errors
+		if e.Problem.Title != "" || len(problems) == 0 {
+			fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[0m\n", e.Problem.Summary())
+		} else {
+			fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[0m\n", problems[0].Summary())
+			problems = problems[1:]
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "\033[31m  %s\033[0m\n", problem.Summary())
+		}
+	}
+	if hint := xurlErrors.Hint(err); hint != "" {
+		fmt.Fprintf(os.Stderr, "\033[33mHint: %s\033[0m\n", hint)
+	}
+}
+
+// PrintPartialErrors warns on stderr when a response has data along with
+// errors for some of the requested resources
+func PrintPartialErrors(response json.RawMessage) {
+	problem := xurlErrors.DecodeProblem(response)
+	if problem == nil || !problem.Partial() {
+		return
+	}
+	for _, e := range problem.Errors {
+		fmt.Fprintf(os.Stderr, "\033[33mWarning: %s\033[0m\n", e.Summary())
+	}
 }
 
 // formatAndPrintResponse formats and prints API responses
@@ -53,7 +191,23 @@ func HandleRequest(options RequestOptions, forceStream bool, mediaFile string, c
 
 	if shouldStream {
 		return ExecuteStreamRequest(options, client)
-	} else {
-		return ExecuteRequest(options, client)
 	}
+
+	// A response that turns out to be a stream is streamed as it is, since
+	// connecting again could exceed the endpoint's connection limit
+	options.keepStream = true
+	err := ExecuteRequest(options, client)
+	var open *openStream
+	if errors.As(err, &open) {
+		// The request was already printed
+		options.Curl = nil
+		var stream StreamOptions
+		if options.Stream != nil {
+			stream = *options.Stream
+		}
+		stream.open = open
+		options.Stream = &stream
+		return ExecuteStreamRequest(options, client)
+	}
+	return err
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
 	"github.com/xdevplatform/xurl/utils"
 )
 
@@ -19,6 +21,7 @@ const (
 
 // MediaUploader handles media upload operations
 type MediaUploader struct {
+	ctx      context.Context
 	client   Client
 	mediaID  string
 	filePath string
@@ -49,6 +52,7 @@ func NewMediaUploader(client Client, filePath string, verbose, trace bool, authT
 	}
 
 	return &MediaUploader{
+		ctx:      context.Background(),
 		client:   client,
 		filePath: filePath,
 		fileSize: fileInfo.Size(),
@@ -62,6 +66,7 @@ func NewMediaUploader(client Client, filePath string, verbose, trace bool, authT
 
 func NewMediaUploaderWithoutFile(client Client, verbose, trace bool, authType string, username string, headers []string) *MediaUploader {
 	return &MediaUploader{
+		ctx:      context.Background(),
 		client:   client,
 		verbose:  verbose,
 		authType: authType,
@@ -71,6 +76,13 @@ func NewMediaUploaderWithoutFile(client Client, verbose, trace bool, authType st
 	}
 }
 
+// WithContext sets the context for the upload. Once it is cancelled the
+// segment being sent is finished and no further segments are sent.
+func (m *MediaUploader) WithContext(ctx context.Context) *MediaUploader {
+	m.ctx = ctx
+	return m
+}
+
 // Init initializes the media upload
 func (m *MediaUploader) Init(mediaType string, mediaCategory string) error {
 	if m.verbose {
@@ -101,9 +113,9 @@ func (m *MediaUploader) Init(mediaType string, mediaCategory string) error {
 		Trace:    m.trace,
 	}
 
-	response, clientErr := m.client.SendRequest(requestOptions)
+	response, clientErr := m.client.SendRequestContext(m.ctx, requestOptions)
 	if clientErr != nil {
-		return fmt.Errorf("init request failed: %v", clientErr)
+		return fmt.Errorf("init request failed: %w", clientErr)
 	}
 
 	var initResponse struct {
@@ -151,6 +163,10 @@ func (m *MediaUploader) Append() error {
 	bytesUploaded := int64(0)
 
 	for {
+		if err := m.ctx.Err(); err != nil {
+			return fmt.Errorf("upload interrupted after %d of %d bytes (media ID %s): %v", bytesUploaded, m.fileSize, m.mediaID, err)
+		}
+
 		bytesRead, err := file.Read(buffer)
 		if err == io.EOF {
 			break
@@ -166,15 +182,17 @@ func (m *MediaUploader) Append() error {
 			"segment_index": strconv.Itoa(segmentIndex),
 		}
 
+		// Re-sending a segment overwrites it, so APPEND can be retried like a PUT
 		requestOptions := RequestOptions{
-			Method:   "POST",
-			Endpoint: finalUrl,
-			Headers:  m.headers,
-			Data:     "",
-			AuthType: m.authType,
-			Username: m.username,
-			Verbose:  m.verbose,
-			Trace:    m.trace,
+			Method:     "POST",
+			Endpoint:   finalUrl,
+			Headers:    m.headers,
+			Data:       "",
+			AuthType:   m.authType,
+			Username:   m.username,
+			Verbose:    m.verbose,
+			Trace:      m.trace,
+			Idempotent: true,
 		}
 		multipartOptions := MultipartOptions{
 			RequestOptions: requestOptions,
@@ -184,11 +202,12 @@ func (m *MediaUploader) Append() error {
 			FileData:       buffer[:bytesRead],
 		}
 
-		// Send multipart request with buffer
-		_, clientErr := m.client.SendMultipartRequest(multipartOptions)
+		// Send multipart request with buffer. Cancellation is only checked
+		// between segments so that a segment is never left half sent.
+		_, clientErr := m.client.SendMultipartRequestContext(context.WithoutCancel(m.ctx), multipartOptions)
 
 		if clientErr != nil {
-			return fmt.Errorf("append request failed: %v", clientErr)
+			return fmt.Errorf("append request failed: %w", clientErr)
 		}
 
 		bytesUploaded += int64(bytesRead)
@@ -227,9 +246,9 @@ func (m *MediaUploader) Finalize() (json.RawMessage, error) {
 		Verbose:  m.verbose,
 		Trace:    m.trace,
 	}
-	response, clientErr := m.client.SendRequest(requestOptions)
+	response, clientErr := m.client.SendRequestContext(m.ctx, requestOptions)
 	if clientErr != nil {
-		return nil, fmt.Errorf("finalize request failed: %v", clientErr)
+		return nil, fmt.Errorf("finalize request failed: %w", clientErr)
 	}
 
 	return response, nil
@@ -257,9 +276,9 @@ func (m *MediaUploader) CheckStatus() (json.RawMessage, error) {
 		Verbose:  m.verbose,
 		Trace:    m.trace,
 	}
-	response, clientErr := m.client.SendRequest(requestOptions)
+	response, clientErr := m.client.SendRequestContext(m.ctx, requestOptions)
 	if clientErr != nil {
-		return nil, fmt.Errorf("status request failed: %v", clientErr)
+		return nil, fmt.Errorf("status request failed: %w", clientErr)
 	}
 
 	if m.verbose {
@@ -320,7 +339,9 @@ func (m *MediaUploader) WaitForProcessing() (json.RawMessage, error) {
 				checkAfterSecs)
 		}
 
-		time.Sleep(time.Duration(checkAfterSecs) * time.Second)
+		if err := sleepContext(m.ctx, time.Duration(checkAfterSecs)*time.Second); err != nil {
+			return nil, fmt.Errorf("stopped waiting for media processing: %v", err)
+		}
 	}
 }
 
@@ -336,10 +357,16 @@ func (m *MediaUploader) SetMediaID(mediaID string) {
 
 // ExecuteMediaUpload handles the media upload command execution
 func ExecuteMediaUpload(filePath, mediaType, mediaCategory, authType, username string, verbose, waitForProcessing, trace bool, headers []string, client Client) error {
+	return ExecuteMediaUploadContext(context.Background(), filePath, mediaType, mediaCategory, authType, username, verbose, waitForProcessing, trace, headers, client)
+}
+
+// ExecuteMediaUploadContext is ExecuteMediaUpload, stopping between chunks once ctx is cancelled
+func ExecuteMediaUploadContext(ctx context.Context, filePath, mediaType, mediaCategory, authType, username string, verbose, waitForProcessing, trace bool, headers []string, client Client) error {
 	uploader, err := NewMediaUploader(client, filePath, verbose, trace, authType, username, headers)
 	if err != nil {
 		return fmt.Errorf("error: %v", err)
 	}
+	uploader.WithContext(ctx)
 
 	if err := uploader.Init(mediaType, mediaCategory); err != nil {
 		return fmt.Errorf("error initializing upload: %v", err)
@@ -372,7 +399,12 @@ func ExecuteMediaUpload(filePath, mediaType, mediaCategory, authType, username s
 
 // ExecuteMediaStatus handles the media status command execution
 func ExecuteMediaStatus(mediaID, authType, username string, verbose, wait, trace bool, headers []string, client Client) error {
-	uploader := NewMediaUploaderWithoutFile(client, verbose, trace, authType, username, headers)
+	return ExecuteMediaStatusContext(context.Background(), mediaID, authType, username, verbose, wait, trace, headers, client)
+}
+
+// ExecuteMediaStatusContext is ExecuteMediaStatus, giving up on waiting once ctx is cancelled
+func ExecuteMediaStatusContext(ctx context.Context, mediaID, authType, username string, verbose, wait, trace bool, headers []string, client Client) error {
+	uploader := NewMediaUploaderWithoutFile(client, verbose, trace, authType, username, headers).WithContext(ctx)
 
 	uploader.SetMediaID(mediaID)
 
@@ -439,7 +471,7 @@ func HandleMediaAppendRequest(options RequestOptions, mediaFile string, client C
 	response, clientErr := client.SendMultipartRequest(multipartOptions)
 
 	if clientErr != nil {
-		return nil, fmt.Errorf("append request failed: %v", clientErr)
+		return nil, fmt.Errorf("append request failed: %w", clientErr)
 	}
 
 	return response, nil
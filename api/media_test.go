@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -45,6 +46,18 @@ func (m *MockApiClient) StreamRequest(options RequestOptions) error {
 	return args.Error(0)
 }
 
+func (m *MockApiClient) SendRequestContext(ctx context.Context, options RequestOptions) (json.RawMessage, error) {
+	return m.SendRequest(options)
+}
+
+func (m *MockApiClient) SendMultipartRequestContext(ctx context.Context, options MultipartOptions) (json.RawMessage, error) {
+	return m.SendMultipartRequest(options)
+}
+
+func (m *MockApiClient) StreamRequestContext(ctx context.Context, options RequestOptions) error {
+	return m.StreamRequest(options)
+}
+
 // Helper function to create a temporary test file
 func createTempTestFile(t *testing.T, size int) (string, []byte) {
 	tempFile, err := os.CreateTemp("", "media_test_*.txt")
@@ -170,13 +183,14 @@ func TestMediaUploader_Append(t *testing.T) {
 	uploader.SetMediaID(mediaID)
 
 	requestOptions := RequestOptions{
-		Method:   "POST",
-		Endpoint: MediaEndpoint + "/" + mediaID + "/append",
-		Headers:  []string{},
-		Data:     "",
-		AuthType: "oauth2",
-		Username: "testuser",
-		Verbose:  false,
+		Method:     "POST",
+		Endpoint:   MediaEndpoint + "/" + mediaID + "/append",
+		Headers:    []string{},
+		Data:       "",
+		AuthType:   "oauth2",
+		Username:   "testuser",
+		Verbose:    false,
+		Idempotent: true,
 	}
 
 	multipartOptions := MultipartOptions{
@@ -209,6 +223,26 @@ func TestMediaUploader_Append(t *testing.T) {
 	assert.Contains(t, err.Error(), "media ID not set")
 }
 
+func TestMediaUploader_AppendCancelled(t *testing.T) {
+	mockClient := new(MockApiClient)
+
+	tempFile, _ := createTempTestFile(t, 1024)
+	defer os.Remove(tempFile)
+
+	uploader, err := NewMediaUploader(mockClient, tempFile, false, false, "oauth2", "testuser", []string{})
+	assert.NoError(t, err)
+	uploader.SetMediaID("test_media_id")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	uploader.WithContext(ctx)
+
+	err = uploader.Append()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "upload interrupted after 0 of 1024 bytes")
+	mockClient.AssertNotCalled(t, "SendMultipartRequest", mock.Anything)
+}
+
 func TestMediaUploader_Finalize(t *testing.T) {
 	mockClient := new(MockApiClient)
 
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// FormPart is a single part of a multipart/form-data body. A part with a
+// FilePath (or Data and a FileName) is sent as a file, anything else as a
+// plain field.
+type FormPart struct {
+	Name  string
+	Value string
+	// FilePath is streamed from disk when the body is sent
+	FilePath string
+	// Data is file content that is already in memory
+	Data []byte
+	// FileName defaults to the base name of FilePath
+	FileName string
+	// ContentType defaults to application/octet-stream for files
+	ContentType string
+}
+
+// IsFile reports whether the part is sent as a file
+func (p FormPart) IsFile() bool {
+	return p.FilePath != "" || p.FileName != "" || p.Data != nil
+}
+
+// ParseFormPart parses a curl-style --form value: "name=value" for a field
+// and "name=@path;type=mime;filename=x" for a file, where type and filename
+// are optional
+func ParseFormPart(spec string) (FormPart, error) {
+	name, value, ok := strings.Cut(spec, "=")
+	if !ok || name == "" {
+		return FormPart{}, xurlErrors.NewHTTPError(fmt.Errorf("invalid --form %q: expected name=value or name=@file", spec))
+	}
+	if !strings.HasPrefix(value, "@") {
+		return FormPart{Name: name, Value: value}, nil
+	}
+
+	attrs := strings.Split(value[1:], ";")
+	part := FormPart{Name: name, FilePath: attrs[0]}
+	if part.FilePath == "" {
+		return FormPart{}, xurlErrors.NewHTTPError(fmt.Errorf("invalid --form %q: missing file path after @", spec))
+	}
+	for _, attr := range attrs[1:] {
+		key, val, _ := strings.Cut(attr, "=")
+		switch strings.TrimSpace(key) {
+		case "type":
+			part.ContentType = val
+		case "filename":
+			part.FileName = val
+		default:
+			return FormPart{}, xurlErrors.NewHTTPError(fmt.Errorf("invalid --form %q: unknown attribute %q (use type= or filename=)", spec, key))
+		}
+	}
+	return part, nil
+}
+
+// multipartBody streams a multipart body: part headers are kept in memory
+// while file contents are read from disk as the request is sent
+type multipartBody struct {
+	io.Reader
+	files []*os.File
+}
+
+// Close closes every file the body reads from
+func (b *multipartBody) Close() error {
+	for _, file := range b.files {
+		file.Close()
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// newMultipartBody builds a streamed multipart body from parts and returns it
+// with its content type and length
+func newMultipartBody(parts []FormPart) (*multipartBody, string, int64, error) {
+	body := &multipartBody{}
+	var readers []io.Reader
+	var length int64
+
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+	flush := func() {
+		if buf.Len() > 0 {
+			length += int64(buf.Len())
+			readers = append(readers, bytes.NewReader(bytes.Clone(buf.Bytes())))
+			buf.Reset()
+		}
+	}
+	fail := func(err error) (*multipartBody, string, int64, error) {
+		body.Close()
+		return nil, "", 0, xurlErrors.NewIOError(err)
+	}
+
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader)
+		if !part.IsFile() {
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name)))
+			if part.ContentType != "" {
+				header.Set("Content-Type", part.ContentType)
+			}
+			w, err := writer.CreatePart(header)
+			if err != nil {
+				return fail(fmt.Errorf("error writing form field: %v", err))
+			}
+			io.WriteString(w, part.Value)
+			continue
+		}
+
+		fileName := part.FileName
+		if fileName == "" {
+			fileName = filepath.Base(part.FilePath)
+		}
+		contentType := part.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(part.Name), quoteEscaper.Replace(fileName)))
+		header.Set("Content-Type", contentType)
+		if _, err := writer.CreatePart(header); err != nil {
+			return fail(fmt.Errorf("error creating form file: %v", err))
+		}
+
+		if part.FilePath == "" {
+			buf.Write(part.Data)
+			continue
+		}
+
+		file, err := os.Open(part.FilePath)
+		if err != nil {
+			return fail(fmt.Errorf("error opening file: %v", err))
+		}
+		body.files = append(body.files, file)
+		info, err := file.Stat()
+		if err != nil {
+			return fail(fmt.Errorf("error reading file: %v", err))
+		}
+		flush()
+		readers = append(readers, file)
+		length += info.Size()
+	}
+
+	if err := writer.Close(); err != nil {
+		return fail(fmt.Errorf("error closing multipart writer: %v", err))
+	}
+	flush()
+
+	body.Reader = io.MultiReader(readers...)
+	return body, writer.FormDataContentType(), length, nil
+}
+
+// multipartParts lists the parts of a multipart request: the single file of
+// FileField, then options.Form in order, then FormFields sorted by name
+func multipartParts(options MultipartOptions) []FormPart {
+	var parts []FormPart
+	if options.FileField != "" && options.FilePath != "" {
+		parts = append(parts, FormPart{Name: options.FileField, FilePath: options.FilePath})
+	} else if options.FileField != "" && len(options.FileData) > 0 {
+		parts = append(parts, FormPart{Name: options.FileField, Data: options.FileData, FileName: options.FileName})
+	}
+
+	parts = append(parts, options.Form...)
+
+	keys := make([]string, 0, len(options.FormFields))
+	for key := range options.FormFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, FormPart{Name: key, Value: options.FormFields[key]})
+	}
+	return parts
+}
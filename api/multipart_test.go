@@ -0,0 +1,96 @@
+package api
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormPart(t *testing.T) {
+	part, err := ParseFormPart("media_category=tweet_image")
+	require.NoError(t, err)
+	assert.Equal(t, FormPart{Name: "media_category", Value: "tweet_image"}, part)
+
+	part, err = ParseFormPart("media=@/tmp/cat.png;type=image/png;filename=kitten.png")
+	require.NoError(t, err)
+	assert.Equal(t, FormPart{Name: "media", FilePath: "/tmp/cat.png", ContentType: "image/png", FileName: "kitten.png"}, part)
+	assert.True(t, part.IsFile())
+
+	_, err = ParseFormPart("=value")
+	assert.Error(t, err)
+	_, err = ParseFormPart("media=@")
+	assert.Error(t, err)
+	_, err = ParseFormPart("media=@cat.png;size=1")
+	assert.Error(t, err)
+}
+
+func TestSendMultipartForm(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.png")
+	second := filepath.Join(dir, "second.bin")
+	require.NoError(t, os.WriteFile(first, []byte("png data"), 0600))
+	require.NoError(t, os.WriteFile(second, []byte("binary data"), 0600))
+
+	type received struct {
+		name, fileName, contentType, content string
+	}
+	var parts []received
+	var contentLength int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			content, _ := io.ReadAll(part)
+			parts = append(parts, received{part.FormName(), part.FileName(), part.Header.Get("Content-Type"), string(content)})
+		}
+		w.Write([]byte(`{"data":{"id":"1"}}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	_, err := client.SendRequest(RequestOptions{
+		Method:   "POST",
+		Endpoint: "/2/media/upload",
+		Form: []FormPart{
+			{Name: "media", FilePath: first, ContentType: "image/png"},
+			{Name: "media_category", Value: "tweet_image"},
+			{Name: "extra", FilePath: second, FileName: "renamed.bin"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []received{
+		{"media", "first.png", "image/png", "png data"},
+		{"media_category", "", "", "tweet_image"},
+		{"extra", "renamed.bin", "application/octet-stream", "binary data"},
+	}, parts)
+	assert.Greater(t, contentLength, int64(0), "The length of a streamed body is known up front")
+}
+
+func TestBuildMultipartRequestMissingFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	_, err := client.BuildRequest(RequestOptions{
+		Method:   "POST",
+		Endpoint: "/2/media/upload",
+		Form:     []FormPart{{Name: "media", FilePath: filepath.Join(t.TempDir(), "missing.png")}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/utils"
+)
+
+// OutputOptions controls how a response is written, mirroring curl's flags
+type OutputOptions struct {
+	// Include prints the status line and headers before the body (-i)
+	Include bool
+	// File writes the body to this file instead of stdout (-o)
+	File string
+	// Raw prints the body exactly as received instead of pretty-printing it
+	Raw bool
+	// WriteOut is a template printed after the response (-w), see ExpandWriteOut
+	WriteOut string
+}
+
+// Response describes the HTTP response a request ended with
+type Response struct {
+	Proto      string
+	Status     string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	URL        string
+	// Duration covers sending the request and reading the whole body
+	Duration time.Duration
+}
+
+// WriteResponse writes a response according to output: headers and body go
+// to output.File or w, and the write-out template always goes to w
+func WriteResponse(w io.Writer, resp *Response, output OutputOptions) error {
+	dst := w
+	if output.File != "" {
+		file, err := os.Create(output.File)
+		if err != nil {
+			return xurlErrors.NewIOError(fmt.Errorf("error creating output file: %v", err))
+		}
+		defer file.Close()
+		dst = file
+	}
+
+	if output.Include {
+		if err := writeHeaders(dst, resp); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	}
+
+	if output.Raw || output.File != "" {
+		if _, err := dst.Write(resp.Body); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	} else if len(resp.Body) > 0 {
+		if err := utils.FormatResponse(dst, json.RawMessage(resp.Body)); err != nil {
+			if _, err := dst.Write(resp.Body); err != nil {
+				return xurlErrors.NewIOError(err)
+			}
+		}
+	}
+
+	if output.WriteOut != "" {
+		if _, err := io.WriteString(w, ExpandWriteOut(output.WriteOut, resp)); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	}
+	return nil
+}
+
+// writeHeaders prints the status line and headers the way curl -i does
+func writeHeaders(w io.Writer, resp *Response) error {
+	proto := resp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	if _, err := fmt.Fprintf(w, "%s %s\r\n", proto, resp.Status); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(resp.Header))
+	for key := range resp.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range resp.Header[key] {
+			if _, err := fmt.Fprintf(w, "%s: %s\r\n", strings.ToLower(key), value); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(w, "\r\n")
+	return err
+}
+
+var writeOutPattern = regexp.MustCompile(`%\{([a-z_]+)\}|%header\{([^}]+)\}|%%|\\[nrt\\]`)
+
+// ExpandWriteOut fills in a --write-out template. Supported variables are
+// %{http_code}, %{time_total}, %{size_download}, %{content_type},
+// %{url_effective}, %{x_rate_limit_limit}, %{x_rate_limit_remaining},
+// %{x_rate_limit_reset}, %{x_transaction_id} and %header{name} for any
+// response header. \n, \r and \t are unescaped; unknown variables expand to
+// nothing.
+func ExpandWriteOut(template string, resp *Response) string {
+	return writeOutPattern.ReplaceAllStringFunc(template, func(match string) string {
+		switch match {
+		case "%%":
+			return "%"
+		case `\n`:
+			return "\n"
+		case `\r`:
+			return "\r"
+		case `\t`:
+			return "\t"
+		case `\\`:
+			return `\`
+		}
+
+		groups := writeOutPattern.FindStringSubmatch(match)
+		if groups[2] != "" {
+			return resp.Header.Get(groups[2])
+		}
+		switch groups[1] {
+		case "http_code", "response_code":
+			return fmt.Sprintf("%03d", resp.StatusCode)
+		case "time_total":
+			return strconv.FormatFloat(resp.Duration.Seconds(), 'f', 6, 64)
+		case "size_download":
+			return strconv.Itoa(len(resp.Body))
+		case "content_type":
+			return resp.Header.Get("Content-Type")
+		case "url_effective":
+			return resp.URL
+		case "x_rate_limit_limit", "x_rate_limit_remaining", "x_rate_limit_reset", "x_transaction_id":
+			return resp.Header.Get(strings.ReplaceAll(groups[1], "_", "-"))
+		}
+		return ""
+	})
+}
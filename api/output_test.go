@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResponse() *Response {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("x-rate-limit-remaining", "14")
+	header.Set("x-transaction-id", "abc123")
+	return &Response{
+		Proto:      "HTTP/1.1",
+		Status:     "200 OK",
+		StatusCode: 200,
+		Header:     header,
+		Body:       []byte(`{"data":{"id":"42"}}`),
+		URL:        "https://api.x.com/2/users/me",
+		Duration:   1500 * time.Millisecond,
+	}
+}
+
+func TestExpandWriteOut(t *testing.T) {
+	resp := testResponse()
+
+	assert.Equal(t, "200 1.500000\n", ExpandWriteOut(`%{http_code} %{time_total}\n`, resp))
+	assert.Equal(t, "14 abc123", ExpandWriteOut("%{x_rate_limit_remaining} %{x_transaction_id}", resp))
+	assert.Equal(t, "application/json", ExpandWriteOut("%header{content-type}", resp))
+	assert.Equal(t, "20 100%", ExpandWriteOut("%{size_download} 100%%", resp))
+	assert.Equal(t, "[]", ExpandWriteOut("[%{unknown}]", resp), "Unknown variables expand to nothing")
+}
+
+func TestWriteResponse(t *testing.T) {
+	t.Run("Include and raw", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := WriteResponse(&buf, testResponse(), OutputOptions{Include: true, Raw: true, WriteOut: "%{http_code}"})
+		require.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, "HTTP/1.1 200 OK\r\n")
+		assert.Contains(t, out, "x-transaction-id: abc123\r\n")
+		assert.Contains(t, out, "\r\n\r\n"+`{"data":{"id":"42"}}`+"200")
+	})
+
+	t.Run("Formatted body", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := WriteResponse(&buf, testResponse(), OutputOptions{WriteOut: "%{http_code}"})
+		require.NoError(t, err)
+
+		out := buf.String()
+		require.True(t, strings.HasSuffix(out, "}\n200"), "The body goes to w before the write-out: %q", out)
+		assert.JSONEq(t, `{"data":{"id":"42"}}`, strings.TrimSuffix(out, "200"))
+		assert.Contains(t, out, "\n    \"id\"", "The body is indented")
+	})
+
+	t.Run("Body to file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out.json")
+		var buf bytes.Buffer
+		err := WriteResponse(&buf, testResponse(), OutputOptions{File: path, WriteOut: "%{http_code}"})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"id":"42"}}`, string(data))
+		assert.Equal(t, "200", buf.String(), "Write-out still goes to stdout")
+	})
+}
+
+func TestOnResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-transaction-id", "tx-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"title":"Not Found Error"}`))
+	}))
+	defer server.Close()
+	client := shortcutClient(t, server)
+
+	var captured *Response
+	_, err := client.SendRequest(RequestOptions{
+		Method:   "GET",
+		Endpoint: "/2/users/404",
+		OnResponse: func(resp *Response) {
+			captured = resp
+		},
+	})
+	require.Error(t, err)
+	require.NotNil(t, captured, "Error responses should be reported too")
+	assert.Equal(t, 404, captured.StatusCode)
+	assert.Equal(t, "tx-1", captured.Header.Get("x-transaction-id"))
+	assert.Equal(t, `{"title":"Not Found Error"}`, string(captured.Body))
+}
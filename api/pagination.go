@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// PaginationOptions controls how list requests are followed across pages
+type PaginationOptions struct {
+	// MaxPages stops after this many pages have been fetched (0 = no limit)
+	MaxPages int
+	// Limit stops once this many items have been collected (0 = no limit)
+	Limit int
+	// OnPage, when set, receives every page as it arrives instead of the
+	// pages being merged into a single response
+	OnPage func(page json.RawMessage) error
+}
+
+// page is the subset of a list response that pagination cares about
+type page struct {
+	Data     json.RawMessage            `json:"data"`
+	Includes map[string]json.RawMessage `json:"includes"`
+	Errors   []json.RawMessage          `json:"errors"`
+	Meta     struct {
+		NextToken string `json:"next_token"`
+	} `json:"meta"`
+}
+
+// mergedResponse is the combined body returned for a paginated request
+type mergedResponse struct {
+	Data     []json.RawMessage            `json:"data,omitempty"`
+	Includes map[string][]json.RawMessage `json:"includes,omitempty"`
+	Errors   []json.RawMessage            `json:"errors,omitempty"`
+	Meta     mergedMeta                   `json:"meta"`
+}
+
+type mergedMeta struct {
+	ResultCount int    `json:"result_count"`
+	Pages       int    `json:"pages"`
+	NextToken   string `json:"next_token,omitempty"`
+}
+
+// SendPaginatedRequest sends a list request and keeps following meta.next_token
+// until the results are exhausted or a limit from options.Pagination is reached.
+// Pages are merged into a single response unless Pagination.OnPage is set, in
+// which case each page is handed to it and the returned response is nil.
+func SendPaginatedRequest(client Client, options RequestOptions) (json.RawMessage, error) {
+	var pagination PaginationOptions
+	if options.Pagination != nil {
+		pagination = *options.Pagination
+	}
+
+	tokenParam := PaginationTokenParam(options.Endpoint)
+	merged := mergedResponse{Includes: make(map[string][]json.RawMessage)}
+	seen := make(map[string]map[string]bool)
+	nextToken := ""
+
+	for {
+		pageOptions := options
+		pageOptions.Pagination = nil
+		if nextToken != "" {
+			endpoint, err := setQueryParam(options.Endpoint, tokenParam, nextToken)
+			if err != nil {
+				return nil, err
+			}
+			pageOptions.Endpoint = endpoint
+		}
+
+		response, err := client.SendRequest(pageOptions)
+		if err != nil {
+			return nil, err
+		}
+		// A dry run only prints the first page's request
+		if response == nil {
+			return nil, nil
+		}
+
+		var p page
+		if err := json.Unmarshal(response, &p); err != nil {
+			return nil, xurlErrors.NewJSONError(err)
+		}
+
+		var items []json.RawMessage
+		if len(p.Data) > 0 && json.Unmarshal(p.Data, &items) != nil {
+			// Not a list endpoint, so there is nothing to follow
+			if pagination.OnPage != nil {
+				return nil, pagination.OnPage(response)
+			}
+			return response, nil
+		}
+
+		if pagination.Limit > 0 && merged.Meta.ResultCount+len(items) > pagination.Limit {
+			items = items[:pagination.Limit-merged.Meta.ResultCount]
+			response, err = replaceData(response, items)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		merged.Meta.Pages++
+		merged.Meta.ResultCount += len(items)
+		merged.Errors = append(merged.Errors, p.Errors...)
+		nextToken = p.Meta.NextToken
+
+		if pagination.OnPage != nil {
+			if err := pagination.OnPage(response); err != nil {
+				return nil, err
+			}
+		} else {
+			merged.Data = append(merged.Data, items...)
+			if err := mergeIncludes(merged.Includes, seen, p.Includes); err != nil {
+				return nil, err
+			}
+		}
+
+		if nextToken == "" ||
+			(pagination.MaxPages > 0 && merged.Meta.Pages >= pagination.MaxPages) ||
+			(pagination.Limit > 0 && merged.Meta.ResultCount >= pagination.Limit) {
+			break
+		}
+	}
+
+	if pagination.OnPage != nil {
+		return nil, nil
+	}
+
+	merged.Meta.NextToken = nextToken
+	result, err := json.Marshal(merged)
+	if err != nil {
+		return nil, xurlErrors.NewJSONError(err)
+	}
+	return result, nil
+}
+
+// PaginationTokenParam returns the query parameter an endpoint expects the
+// previous page's next_token in. Search and counts endpoints take next_token,
+// everything else takes pagination_token.
+func PaginationTokenParam(endpoint string) string {
+	path := endpoint
+	if idx := strings.Index(path, "?"); idx != -1 {
+		path = path[:idx]
+	}
+	if strings.Contains(path, "/search/") || strings.Contains(path, "/counts/") {
+		return "next_token"
+	}
+	return "pagination_token"
+}
+
+// setQueryParam sets a query parameter on an endpoint, which may be a path or a full URL
+func setQueryParam(endpoint, key, value string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", xurlErrors.NewHTTPError(fmt.Errorf("invalid endpoint %q: %v", endpoint, err))
+	}
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// replaceData swaps the data array of a page for a truncated one
+func replaceData(response json.RawMessage, items []json.RawMessage) (json.RawMessage, error) {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(response, &body); err != nil {
+		return nil, xurlErrors.NewJSONError(err)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, xurlErrors.NewJSONError(err)
+	}
+	body["data"] = data
+	result, err := json.Marshal(body)
+	if err != nil {
+		return nil, xurlErrors.NewJSONError(err)
+	}
+	return result, nil
+}
+
+// mergeIncludes appends expansion objects from a page, skipping ones that an
+// earlier page already included (e.g. the same author on several pages)
+func mergeIncludes(dst map[string][]json.RawMessage, seen map[string]map[string]bool, includes map[string]json.RawMessage) error {
+	for kind, raw := range includes {
+		var objects []json.RawMessage
+		if err := json.Unmarshal(raw, &objects); err != nil {
+			return xurlErrors.NewJSONError(err)
+		}
+		if seen[kind] == nil {
+			seen[kind] = make(map[string]bool)
+		}
+		for _, object := range objects {
+			var key struct {
+				ID       string `json:"id"`
+				MediaKey string `json:"media_key"`
+			}
+			_ = json.Unmarshal(object, &key)
+			id := key.ID + key.MediaKey
+			if id == "" {
+				id = string(object)
+			}
+			if seen[kind][id] {
+				continue
+			}
+			seen[kind][id] = true
+			dst[kind] = append(dst[kind], object)
+		}
+	}
+	return nil
+}
+
+// CompactJSON renders a response on a single line, for NDJSON output
+func CompactJSON(response json.RawMessage) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, response); err != nil {
+		return "", xurlErrors.NewJSONError(err)
+	}
+	return buf.String(), nil
+}
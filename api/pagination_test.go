@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPaginatedServer serves three pages of followers, each sharing the
+// same pinned post in includes so de-duplication can be checked.
+func setupPaginatedServer(t *testing.T) (*httptest.Server, *[]string) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		token := r.URL.Query().Get("pagination_token")
+		tokens = append(tokens, token)
+
+		var page int
+		switch token {
+		case "":
+			page = 1
+		case "p2":
+			page = 2
+		case "p3":
+			page = 3
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		next := ""
+		if page < 3 {
+			next = fmt.Sprintf(`,"next_token":"p%d"`, page+1)
+		}
+		fmt.Fprintf(w, `{"data":[{"id":"%d1"},{"id":"%d2"}],"includes":{"tweets":[{"id":"pinned"}]},"meta":{"result_count":2%s}}`, page, page, next)
+	}))
+	t.Cleanup(server.Close)
+	return server, &tokens
+}
+
+func TestSendPaginatedRequest(t *testing.T) {
+	t.Run("Merges all pages", func(t *testing.T) {
+		server, tokens := setupPaginatedServer(t)
+		client := shortcutClient(t, server)
+
+		opts := RequestOptions{
+			Method:     "GET",
+			Endpoint:   "/2/users/42/followers?max_results=2",
+			Pagination: &PaginationOptions{},
+		}
+		resp, err := SendPaginatedRequest(client, opts)
+		require.NoError(t, err)
+
+		var result mergedResponse
+		require.NoError(t, json.Unmarshal(resp, &result))
+		assert.Len(t, result.Data, 6)
+		assert.Len(t, result.Includes["tweets"], 1, "Duplicate includes should be dropped")
+		assert.Equal(t, 6, result.Meta.ResultCount)
+		assert.Equal(t, 3, result.Meta.Pages)
+		assert.Empty(t, result.Meta.NextToken)
+		assert.Equal(t, []string{"", "p2", "p3"}, *tokens)
+	})
+
+	t.Run("Stops at max pages", func(t *testing.T) {
+		server, _ := setupPaginatedServer(t)
+		client := shortcutClient(t, server)
+
+		opts := RequestOptions{
+			Method:     "GET",
+			Endpoint:   "/2/users/42/followers",
+			Pagination: &PaginationOptions{MaxPages: 2},
+		}
+		resp, err := SendPaginatedRequest(client, opts)
+		require.NoError(t, err)
+
+		var result mergedResponse
+		require.NoError(t, json.Unmarshal(resp, &result))
+		assert.Len(t, result.Data, 4)
+		assert.Equal(t, "p3", result.Meta.NextToken, "Remaining next_token should be reported")
+	})
+
+	t.Run("Truncates at limit", func(t *testing.T) {
+		server, tokens := setupPaginatedServer(t)
+		client := shortcutClient(t, server)
+
+		opts := RequestOptions{
+			Method:     "GET",
+			Endpoint:   "/2/users/42/followers",
+			Pagination: &PaginationOptions{Limit: 3},
+		}
+		resp, err := SendPaginatedRequest(client, opts)
+		require.NoError(t, err)
+
+		var result mergedResponse
+		require.NoError(t, json.Unmarshal(resp, &result))
+		assert.Len(t, result.Data, 3)
+		assert.Len(t, *tokens, 2)
+	})
+
+	t.Run("Hands pages to OnPage", func(t *testing.T) {
+		server, _ := setupPaginatedServer(t)
+		client := shortcutClient(t, server)
+
+		var pages []json.RawMessage
+		opts := RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/users/42/followers",
+			Pagination: &PaginationOptions{
+				Limit: 5,
+				OnPage: func(page json.RawMessage) error {
+					pages = append(pages, page)
+					return nil
+				},
+			},
+		}
+		resp, err := SendPaginatedRequest(client, opts)
+		require.NoError(t, err)
+		assert.Nil(t, resp)
+		require.Len(t, pages, 3)
+
+		var last struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(pages[2], &last))
+		assert.Len(t, last.Data, 1, "Last page should be truncated to the limit")
+	})
+
+	t.Run("Non-list response is returned as is", func(t *testing.T) {
+		server := setupShortcutServer()
+		defer server.Close()
+		client := shortcutClient(t, server)
+
+		opts := RequestOptions{
+			Method:     "GET",
+			Endpoint:   "/2/users/me",
+			Pagination: &PaginationOptions{},
+		}
+		resp, err := SendPaginatedRequest(client, opts)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":{"id":"42","username":"testbot","name":"Test Bot"}}`, string(resp))
+	})
+}
+
+func TestPaginationTokenParam(t *testing.T) {
+	assert.Equal(t, "next_token", PaginationTokenParam("/2/tweets/search/recent?query=go"))
+	assert.Equal(t, "next_token", PaginationTokenParam("/2/tweets/counts/recent?query=go"))
+	assert.Equal(t, "pagination_token", PaginationTokenParam("/2/users/1/followers"))
+	assert.Equal(t, "pagination_token", PaginationTokenParam("/2/dm_events?max_results=10"))
+}
+
+func TestCompactJSON(t *testing.T) {
+	line, err := CompactJSON(json.RawMessage("{\n  \"data\": [1, 2]\n}"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":[1,2]}`, line)
+}
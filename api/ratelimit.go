@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// RateLimit holds the x-rate-limit-* values reported for an endpoint
+type RateLimit = xurlErrors.RateLimit
+
+const (
+	// maxRateLimitWaits caps how many times a single request waits out a 429
+	maxRateLimitWaits = 3
+	// defaultRateLimitWait is used when a 429 arrives without a reset header
+	defaultRateLimitWait = time.Minute
+)
+
+// ParseRateLimit reads the x-rate-limit-limit, x-rate-limit-remaining and
+// x-rate-limit-reset headers. It returns nil if the response carries none.
+func ParseRateLimit(header http.Header) *RateLimit {
+	limit, errLimit := strconv.Atoi(header.Get("x-rate-limit-limit"))
+	remaining, errRemaining := strconv.Atoi(header.Get("x-rate-limit-remaining"))
+	reset, errReset := strconv.ParseInt(header.Get("x-rate-limit-reset"), 10, 64)
+	if errLimit != nil && errRemaining != nil && errReset != nil {
+		return nil
+	}
+
+	rateLimit := &RateLimit{Limit: limit, Remaining: remaining}
+	if errReset == nil {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+	return rateLimit
+}
+
+// PaceDelay returns how long to wait before the next request so that the
+// remaining budget lasts until the window resets. Requests are not slowed
+// down until the last quarter of the budget; once it is exhausted the delay
+// is the time left until reset.
+func PaceDelay(rateLimit *RateLimit) time.Duration {
+	if rateLimit == nil || rateLimit.Reset.IsZero() {
+		return 0
+	}
+	resetIn := rateLimit.ResetIn()
+	if resetIn == 0 {
+		return 0
+	}
+	if rateLimit.Remaining <= 0 {
+		return resetIn + time.Second
+	}
+	if rateLimit.Remaining*4 >= rateLimit.Limit {
+		return 0
+	}
+	return resetIn / time.Duration(rateLimit.Remaining+1)
+}
+
+// rateLimitWait returns how long to sleep after a 429 before trying again
+func rateLimitWait(rateLimit *RateLimit) time.Duration {
+	if rateLimit == nil || rateLimit.Reset.IsZero() {
+		return defaultRateLimitWait
+	}
+	return rateLimit.ResetIn() + time.Second
+}
+
+// rateLimitKey identifies the endpoint a rate limit was reported for
+func rateLimitKey(req *http.Request) string {
+	return req.Method + " " + req.URL.Path
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	t.Run("All headers present", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("x-rate-limit-limit", "15")
+		header.Set("x-rate-limit-remaining", "3")
+		header.Set("x-rate-limit-reset", "1700000000")
+
+		rateLimit := ParseRateLimit(header)
+		require.NotNil(t, rateLimit)
+		assert.Equal(t, 15, rateLimit.Limit)
+		assert.Equal(t, 3, rateLimit.Remaining)
+		assert.Equal(t, time.Unix(1700000000, 0), rateLimit.Reset)
+	})
+
+	t.Run("No headers", func(t *testing.T) {
+		assert.Nil(t, ParseRateLimit(http.Header{}))
+	})
+}
+
+func TestPaceDelay(t *testing.T) {
+	reset := time.Now().Add(100 * time.Second)
+
+	assert.Zero(t, PaceDelay(nil))
+	assert.Zero(t, PaceDelay(&RateLimit{Limit: 100, Remaining: 80, Reset: reset}), "Plenty of budget left")
+	assert.Zero(t, PaceDelay(&RateLimit{Limit: 100, Remaining: 0, Reset: time.Now().Add(-time.Second)}), "Window already reset")
+
+	delay := PaceDelay(&RateLimit{Limit: 100, Remaining: 9, Reset: reset})
+	assert.InDelta(t, 10*time.Second, delay, float64(time.Second))
+
+	delay = PaceDelay(&RateLimit{Limit: 100, Remaining: 0, Reset: reset})
+	assert.Greater(t, delay, 100*time.Second)
+}
+
+func TestSendRequestRateLimited(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-rate-limit-limit", "15")
+		if calls == 1 {
+			w.Header().Set("x-rate-limit-remaining", "0")
+			w.Header().Set("x-rate-limit-reset", fmt.Sprint(time.Now().Unix()))
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"title":"Too Many Requests","status":429}`))
+			return
+		}
+		w.Header().Set("x-rate-limit-remaining", "14")
+		w.Header().Set("x-rate-limit-reset", fmt.Sprint(time.Now().Add(15*time.Minute).Unix()))
+		w.Write([]byte(`{"data":{"id":"42"}}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	cfg := &config.Config{APIBaseURL: server.URL}
+
+	t.Run("Returns a rate limit error", func(t *testing.T) {
+		calls = 0
+		client := NewApiClient(cfg, authMock)
+
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.Error(t, err)
+		assert.True(t, xurlErrors.IsRateLimitError(err), "Expected rate limit error")
+		assert.True(t, xurlErrors.IsAPIError(err), "A 429 is still an API error")
+
+		var e *xurlErrors.Error
+		require.ErrorAs(t, err, &e)
+		require.NotNil(t, e.RateLimit)
+		assert.Equal(t, 15, e.RateLimit.Limit)
+		assert.Equal(t, 0, e.RateLimit.Remaining)
+		assert.Equal(t, e.RateLimit, client.LastRateLimit())
+	})
+
+	t.Run("Waits and retries when enabled", func(t *testing.T) {
+		calls = 0
+		client := NewApiClient(cfg, authMock).WithWaitOnLimit(true)
+
+		resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":{"id":"42"}}`, string(resp))
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 14, client.LastRateLimit().Remaining)
+	})
+}
+
+func TestRateLimitWaitsAfterRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case calls <= 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"title":"Service Unavailable"}`))
+		case calls == 4:
+			w.Header().Set("x-rate-limit-limit", "15")
+			w.Header().Set("x-rate-limit-remaining", "0")
+			w.Header().Set("x-rate-limit-reset", fmt.Sprint(time.Now().Unix()))
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"title":"Too Many Requests","status":429}`))
+		default:
+			w.Write([]byte(`{"data":{"id":"42"}}`))
+		}
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock).
+		WithWaitOnLimit(true).
+		WithRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond})
+
+	resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+	require.NoError(t, err, "Retries of 5xx responses don't use up the rate limit waits")
+	assert.JSONEq(t, `{"data":{"id":"42"}}`, string(resp))
+	assert.Equal(t, 5, calls)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRetryBaseDelay is the backoff before the first retry
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	// defaultRetryMaxDelay caps the backoff between two attempts
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryPolicy controls how requests that fail with a network error, a 5xx or
+// a 429 are retried. The zero value disables retries.
+type RetryPolicy struct {
+	// MaxRetries is how many times a request is sent again after the first attempt
+	MaxRetries int
+	// MaxTime stops retrying once this much time has passed since the first
+	// attempt (0 = no limit)
+	MaxTime time.Duration
+	// RetryPOST allows retrying POST and PATCH requests, which may not be
+	// idempotent. GET, HEAD, OPTIONS, PUT and DELETE are always retried.
+	RetryPOST bool
+	// BaseDelay is the backoff before the first retry; it doubles on every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between two attempts
+	MaxDelay time.Duration
+}
+
+// canRetry reports whether a request with the given method may be sent again
+func (p RetryPolicy) canRetry(method string, idempotent bool) bool {
+	if p.MaxRetries <= 0 {
+		return false
+	}
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return idempotent || p.RetryPOST
+}
+
+// backoff returns a jittered delay before retry number attempt (starting at 1).
+// The delay is picked at random between half and all of the exponential step
+// so that concurrent clients don't retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleepContext waits for d, returning early with the context's error if it
+// is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+)
+
+func TestRetryPolicyCanRetry(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2}
+	assert.True(t, policy.canRetry("GET", false))
+	assert.True(t, policy.canRetry("delete", false))
+	assert.False(t, policy.canRetry("POST", false), "POST should not be retried by default")
+	assert.True(t, policy.canRetry("POST", true), "Idempotent POST should be retried")
+
+	policy.RetryPOST = true
+	assert.True(t, policy.canRetry("POST", false))
+
+	assert.False(t, RetryPolicy{}.canRetry("GET", false), "Zero policy disables retries")
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for i := 0; i < 20; i++ {
+		delay := policy.backoff(1)
+		assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+		assert.LessOrEqual(t, delay, 100*time.Millisecond)
+
+		delay = policy.backoff(3)
+		assert.GreaterOrEqual(t, delay, 200*time.Millisecond)
+		assert.LessOrEqual(t, delay, 400*time.Millisecond)
+
+		delay = policy.backoff(10)
+		assert.LessOrEqual(t, delay, time.Second, "Backoff should be capped at MaxDelay")
+	}
+}
+
+func TestSendRequestRetries(t *testing.T) {
+	calls := 0
+	failures := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"title":"Service Unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"id":"42"}}`))
+	}))
+	defer server.Close()
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+	cfg := &config.Config{APIBaseURL: server.URL}
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}
+
+	t.Run("GET is retried until it succeeds", func(t *testing.T) {
+		calls, failures = 0, 2
+		client := NewApiClient(cfg, authMock).WithRetryPolicy(policy)
+
+		resp, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"data":{"id":"42"}}`, string(resp))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Gives up after MaxRetries", func(t *testing.T) {
+		calls, failures = 0, 10
+		client := NewApiClient(cfg, authMock).WithRetryPolicy(policy)
+
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.Error(t, err)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("POST is not retried by default", func(t *testing.T) {
+		calls, failures = 0, 1
+		client := NewApiClient(cfg, authMock).WithRetryPolicy(policy)
+
+		_, err := client.SendRequest(RequestOptions{Method: "POST", Endpoint: "/2/tweets", Data: `{"text":"hi"}`})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Idempotent multipart POST is retried", func(t *testing.T) {
+		calls, failures = 0, 1
+		client := NewApiClient(cfg, authMock).WithRetryPolicy(policy)
+
+		_, err := client.SendMultipartRequest(MultipartOptions{
+			RequestOptions: RequestOptions{Method: "POST", Endpoint: "/2/media/upload/1/append", Idempotent: true},
+			FormFields:     map[string]string{"segment_index": "0"},
+			FileField:      "media",
+			FileName:       "chunk",
+			FileData:       []byte("chunk"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Network errors are retried", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		client := NewApiClient(&config.Config{APIBaseURL: closed.URL}, authMock).WithRetryPolicy(RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond})
+
+		start := time.Now()
+		_, err := client.SendRequest(RequestOptions{Method: "GET", Endpoint: "/2/users/me"})
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// StreamRulesEndpoint manages the rules of the filtered stream
+const StreamRulesEndpoint = "/2/tweets/search/stream/rules"
+
+// StreamRule is a filtered stream rule. ID is assigned by X when the rule
+// is added.
+type StreamRule struct {
+	ID    string `json:"id,omitempty" yaml:"id,omitempty"`
+	Value string `json:"value" yaml:"value"`
+	Tag   string `json:"tag,omitempty" yaml:"tag,omitempty"`
+}
+
+// key identifies a rule by what it matches and how it's tagged, which is
+// all a rules file knows about it
+func (r StreamRule) key() string {
+	return r.Value + "\x00" + r.Tag
+}
+
+// String returns the rule the way it's shown to users
+func (r StreamRule) String() string {
+	s := r.Value
+	if r.Tag != "" {
+		s += fmt.Sprintf(" (tag %q)", r.Tag)
+	}
+	if r.ID != "" {
+		s = r.ID + "  " + s
+	}
+	return s
+}
+
+// ListStreamRules fetches the filtered stream rules.
+func ListStreamRules(client Client, opts RequestOptions) (json.RawMessage, error) {
+	opts.Method = "GET"
+	opts.Endpoint = StreamRulesEndpoint
+	opts.Data = ""
+	return client.SendRequest(opts)
+}
+
+// DecodeStreamRules returns the rules in a rules response's data
+func DecodeStreamRules(response json.RawMessage) ([]StreamRule, error) {
+	var body struct {
+		Data []StreamRule `json:"data"`
+	}
+	if err := json.Unmarshal(response, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode stream rules: %w", err)
+	}
+	return body.Data, nil
+}
+
+// AddStreamRules adds filtered stream rules. With dryRun, X only validates
+// them.
+func AddStreamRules(client Client, rules []StreamRule, dryRun bool, opts RequestOptions) (json.RawMessage, error) {
+	add := make([]StreamRule, len(rules))
+	for i, rule := range rules {
+		add[i] = StreamRule{Value: rule.Value, Tag: rule.Tag}
+	}
+	return sendStreamRules(client, map[string]any{"add": add}, dryRun, opts)
+}
+
+// DeleteStreamRules deletes filtered stream rules by ID. With dryRun, X
+// only validates the request.
+func DeleteStreamRules(client Client, ids []string, dryRun bool, opts RequestOptions) (json.RawMessage, error) {
+	body := map[string]any{"delete": map[string][]string{"ids": ids}}
+	return sendStreamRules(client, body, dryRun, opts)
+}
+
+func sendStreamRules(client Client, body any, dryRun bool, opts RequestOptions) (json.RawMessage, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rules body: %w", err)
+	}
+
+	opts.Method = "POST"
+	opts.Endpoint = StreamRulesEndpoint
+	if dryRun {
+		opts.Endpoint += "?dry_run=true"
+	}
+	opts.Data = string(data)
+
+	return client.SendRequest(opts)
+}
+
+// ResolveStreamRuleIDs turns rule IDs and tags into the IDs of the rules
+// they name. A tag names every rule with that tag.
+func ResolveStreamRuleIDs(rules []StreamRule, idsOrTags []string) ([]string, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, arg := range idsOrTags {
+		found := false
+		for _, rule := range rules {
+			if rule.ID == arg || rule.Tag == arg {
+				found = true
+				if !seen[rule.ID] {
+					seen[rule.ID] = true
+					ids = append(ids, rule.ID)
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no stream rule has the ID or tag %q", arg)
+		}
+	}
+	return ids, nil
+}
+
+// LoadStreamRules reads rules from a YAML or JSON file holding either a
+// list of rules or an object with the list under "rules" (or "data", so the
+// output of 'xurl stream rules list' can be used as is):
+//
+//	rules:
+//	  - value: "golang -is:retweet"
+//	    tag: golang
+func LoadStreamRules(path string) ([]StreamRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	// YAML is a superset of JSON, so one decoder reads both
+	var rules []StreamRule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		var file struct {
+			Rules []StreamRule `yaml:"rules"`
+			Data  []StreamRule `yaml:"data"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		rules = append(file.Rules, file.Data...)
+	}
+
+	for i, rule := range rules {
+		rules[i].Value = strings.TrimSpace(rule.Value)
+		if rules[i].Value == "" {
+			return nil, fmt.Errorf("%s: rule %d has no value", path, i+1)
+		}
+	}
+	return rules, nil
+}
+
+// PlanStreamRulesSync works out the changes that turn the current rules
+// into the desired ones. Rules can't be edited, so a rule whose tag changed
+// is deleted and added again.
+func PlanStreamRulesSync(current, desired []StreamRule) (add, remove []StreamRule) {
+	want := map[string]bool{}
+	for _, rule := range desired {
+		want[rule.key()] = true
+	}
+	have := map[string]bool{}
+	for _, rule := range current {
+		if !want[rule.key()] || have[rule.key()] {
+			remove = append(remove, rule)
+			continue
+		}
+		have[rule.key()] = true
+	}
+	for _, rule := range desired {
+		if !have[rule.key()] {
+			have[rule.key()] = true
+			add = append(add, rule)
+		}
+	}
+	return add, remove
+}
+
+// StreamRulesSync is what SyncStreamRules changed, or would change
+type StreamRulesSync struct {
+	Added   []StreamRule
+	Deleted []StreamRule
+}
+
+// SyncStreamRules makes the filtered stream rules match desired: rules that
+// are missing are added and rules that aren't wanted are deleted. The new
+// rules are validated with X first, so nothing is deleted when an added rule
+// would be rejected. With dryRun only the validation is done.
+func SyncStreamRules(client Client, desired []StreamRule, dryRun bool, opts RequestOptions) (*StreamRulesSync, error) {
+	response, err := ListStreamRules(client, opts)
+	if err != nil {
+		return nil, err
+	}
+	current, err := DecodeStreamRules(response)
+	if err != nil {
+		return nil, err
+	}
+
+	add, remove := PlanStreamRulesSync(current, desired)
+	result := &StreamRulesSync{Added: add, Deleted: remove}
+
+	// Rules that only change their tag would be reported as duplicates of
+	// themselves; they were valid when they were added
+	removed := map[string]bool{}
+	for _, rule := range remove {
+		removed[rule.Value] = true
+	}
+	var validate []StreamRule
+	for _, rule := range add {
+		if !removed[rule.Value] {
+			validate = append(validate, rule)
+		}
+	}
+	if len(validate) > 0 {
+		response, err := AddStreamRules(client, validate, true, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := streamRulesError(response); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	if len(remove) > 0 {
+		ids := make([]string, len(remove))
+		for i, rule := range remove {
+			ids[i] = rule.ID
+		}
+		if _, err := DeleteStreamRules(client, ids, false, opts); err != nil {
+			return nil, err
+		}
+	}
+	if len(add) > 0 {
+		response, err := AddStreamRules(client, add, false, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := streamRulesError(response); err != nil {
+			return nil, err
+		}
+		if result.Added, err = DecodeStreamRules(response); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// streamRulesError returns an error for the rules X refused in an add
+// response, which otherwise succeeds for the rest
+func streamRulesError(response json.RawMessage) error {
+	if problem := xurlErrors.DecodeProblem(response); problem != nil && len(problem.Errors) > 0 {
+		return xurlErrors.NewAPIError(response)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/mock"
+)
+
+func TestPlanStreamRulesSync(t *testing.T) {
+	current := []StreamRule{
+		{ID: "1", Value: "cats", Tag: "pets"},
+		{ID: "2", Value: "dogs", Tag: "pets"},
+		{ID: "3", Value: "old"},
+	}
+	desired := []StreamRule{
+		{Value: "cats", Tag: "felines"},
+		{Value: "dogs", Tag: "pets"},
+		{Value: "golang"},
+		{Value: "golang"},
+	}
+
+	add, remove := PlanStreamRulesSync(current, desired)
+	assert.Equal(t, []StreamRule{{Value: "cats", Tag: "felines"}, {Value: "golang"}}, add)
+	assert.Equal(t, []StreamRule{current[0], current[2]}, remove, "Retagged rules are replaced")
+
+	add, remove = PlanStreamRulesSync(current, current)
+	assert.Empty(t, add)
+	assert.Empty(t, remove)
+}
+
+func TestResolveStreamRuleIDs(t *testing.T) {
+	rules := []StreamRule{
+		{ID: "1", Value: "cats", Tag: "pets"},
+		{ID: "2", Value: "dogs", Tag: "pets"},
+		{ID: "3", Value: "golang", Tag: "go"},
+	}
+
+	ids, err := ResolveStreamRuleIDs(rules, []string{"pets", "3", "1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+
+	_, err = ResolveStreamRuleIDs(rules, []string{"birds"})
+	assert.EqualError(t, err, `no stream rule has the ID or tag "birds"`)
+}
+
+func TestLoadStreamRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	want := []StreamRule{{Value: "golang -is:retweet", Tag: "go"}, {Value: "from:XDevelopers"}}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"YAML list", "- value: golang -is:retweet\n  tag: go\n- value: from:XDevelopers\n"},
+		{"YAML rules", "rules:\n  - value: golang -is:retweet\n    tag: go\n  - value: from:XDevelopers\n"},
+		{"JSON list", `[{"value":"golang -is:retweet","tag":"go"},{"value":"from:XDevelopers"}]`},
+		{"rules list output", `{"data":[{"id":"1","value":"golang -is:retweet","tag":"go"},{"id":"2","value":"from:XDevelopers"}],"meta":{}}`},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := LoadStreamRules(write(fmt.Sprintf("rules%d", i), tt.content))
+			require.NoError(t, err)
+			for i := range rules {
+				rules[i].ID = ""
+			}
+			assert.Equal(t, want, rules)
+		})
+	}
+
+	_, err := LoadStreamRules(write("empty.yaml", "\n"))
+	assert.Error(t, err)
+	_, err = LoadStreamRules(write("blank.yaml", "rules:\n  - tag: go\n"))
+	assert.ErrorContains(t, err, "rule 1 has no value")
+}
+
+func TestSyncStreamRules(t *testing.T) {
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	server := httptest.NewServer(mock.NewServer())
+	defer server.Close()
+
+	client := NewApiClient(&config.Config{APIBaseURL: server.URL}, authMock)
+	opts := RequestOptions{AuthType: "app"}
+
+	_, err := AddStreamRules(client, []StreamRule{{Value: "cats", Tag: "pets"}, {Value: "old"}}, false, opts)
+	require.NoError(t, err)
+
+	desired := []StreamRule{{Value: "cats", Tag: "felines"}, {Value: "golang"}}
+	result, err := SyncStreamRules(client, desired, true, opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Added, 2)
+	assert.Len(t, result.Deleted, 2)
+
+	current := func() []StreamRule {
+		response, err := ListStreamRules(client, opts)
+		require.NoError(t, err)
+		rules, err := DecodeStreamRules(response)
+		require.NoError(t, err)
+		for i := range rules {
+			rules[i].ID = ""
+		}
+		return rules
+	}
+	assert.Equal(t, []StreamRule{{Value: "cats", Tag: "pets"}, {Value: "old"}}, current(), "Dry runs change nothing")
+
+	result, err = SyncStreamRules(client, desired, false, opts)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Added[0].ID, "Added rules carry their new IDs")
+	assert.Equal(t, desired, current())
+
+	t.Run("Invalid rules change nothing", func(t *testing.T) {
+		_, err := SyncStreamRules(client, []StreamRule{{Value: "dogs"}, {Value: ""}}, false, opts)
+		require.Error(t, err)
+		assert.Equal(t, xurlErrors.ExitInvalidRequest, xurlErrors.ExitCode(err))
+		assert.Equal(t, desired, current())
+	})
+}
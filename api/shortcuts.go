@@ -68,6 +68,15 @@ func ResolveUsername(input string) string {
 	return strings.TrimPrefix(strings.TrimSpace(input), "@")
 }
 
+// sendList sends a list request, following pagination tokens when
+// opts.Pagination is set.
+func sendList(client Client, opts RequestOptions) (json.RawMessage, error) {
+	if opts.Pagination != nil {
+		return SendPaginatedRequest(client, opts)
+	}
+	return client.SendRequest(opts)
+}
+
 // ------------------------------------------------
 // Shortcut executors
 // ------------------------------------------------
@@ -173,7 +182,7 @@ func SearchPosts(client Client, query string, maxResults int, opts RequestOption
 	opts.Endpoint = fmt.Sprintf("/2/tweets/search/recent?query=%s&max_results=%d&tweet.fields=created_at,public_metrics,conversation_id,entities&expansions=author_id&user.fields=username,name,verified", q, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // GetMe fetches the authenticated user's profile.
@@ -202,7 +211,7 @@ func GetUserPosts(client Client, userID string, maxResults int, opts RequestOpti
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/tweets?max_results=%d&tweet.fields=created_at,public_metrics,conversation_id,entities&expansions=referenced_tweets.id", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // GetTimeline fetches the authenticated user's reverse‑chronological timeline.
@@ -212,7 +221,7 @@ func GetTimeline(client Client, userID string, maxResults int, opts RequestOptio
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/timelines/reverse_chronological?max_results=%d&tweet.fields=created_at,public_metrics,conversation_id,entities&expansions=author_id&user.fields=username,name", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // GetMentions fetches recent mentions for a user.
@@ -221,7 +230,7 @@ func GetMentions(client Client, userID string, maxResults int, opts RequestOptio
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/mentions?max_results=%d&tweet.fields=created_at,public_metrics,conversation_id,entities&expansions=author_id&user.fields=username,name", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // LikePost likes a post on behalf of the authenticated user.
@@ -302,7 +311,7 @@ func GetBookmarks(client Client, userID string, maxResults int, opts RequestOpti
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/bookmarks?max_results=%d&tweet.fields=created_at,public_metrics,entities&expansions=author_id&user.fields=username,name", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // FollowUser follows a user.
@@ -331,7 +340,7 @@ func GetFollowing(client Client, userID string, maxResults int, opts RequestOpti
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/following?max_results=%d&user.fields=created_at,description,public_metrics,verified", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // GetFollowers fetches followers of a given user.
@@ -340,7 +349,7 @@ func GetFollowers(client Client, userID string, maxResults int, opts RequestOpti
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/followers?max_results=%d&user.fields=created_at,description,public_metrics,verified", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // SendDM sends a direct message to a user.
@@ -360,7 +369,7 @@ func GetDMEvents(client Client, maxResults int, opts RequestOptions) (json.RawMe
 	opts.Endpoint = fmt.Sprintf("/2/dm_events?max_results=%d&dm_event.fields=created_at,dm_conversation_id,sender_id,text&expansions=sender_id&user.fields=username,name", maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // GetLikedPosts fetches posts liked by a user.
@@ -369,7 +378,7 @@ func GetLikedPosts(client Client, userID string, maxResults int, opts RequestOpt
 	opts.Endpoint = fmt.Sprintf("/2/users/%s/liked_tweets?max_results=%d&tweet.fields=created_at,public_metrics,entities&expansions=author_id&user.fields=username,name", userID, maxResults)
 	opts.Data = ""
 
-	return client.SendRequest(opts)
+	return sendList(client, opts)
 }
 
 // BlockUser blocks a user.
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an append-only file for streamed lines that moves aside
+// once it reaches MaxSize bytes or has been open for MaxAge, so long running
+// streams can be kept without one file growing forever. Rotated files are
+// named after the time they were rotated, e.g. posts-20250102T150405Z.ndjson,
+// and gzipped in the background when Gzip is set.
+type RotatingFile struct {
+	Path    string
+	MaxSize int64
+	MaxAge  time.Duration
+	Gzip    bool
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+
+	// compressing tracks background gzips, the first of which to fail
+	// leaves its error in compressErr for Close
+	compressing sync.WaitGroup
+	compressErr error
+}
+
+// OpenRotatingFile opens path for appending, creating it if needed. A zero
+// maxSize or maxAge turns that kind of rotation off.
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, compress bool) (*RotatingFile, error) {
+	f := &RotatingFile{Path: path, MaxSize: maxSize, MaxAge: maxAge, Gzip: compress, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.now()
+	return nil
+}
+
+// Write appends p, rotating first if the file is due. Callers write whole
+// lines so that a line never straddles two files.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether writing n more bytes should go to a new file. An
+// empty file is never rotated, so a line larger than MaxSize still gets
+// written.
+func (f *RotatingFile) due(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.MaxSize > 0 && f.size+n > f.MaxSize {
+		return true
+	}
+	return f.MaxAge > 0 && f.now().Sub(f.opened) >= f.MaxAge
+}
+
+// rotate closes the current file, moves it aside and opens a new one. If
+// that fails, Path is reopened so that later writes still have somewhere to
+// go.
+func (f *RotatingFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return errors.Join(err, f.open())
+	}
+
+	rotated := f.rotatedName()
+	if err := os.Rename(f.Path, rotated); err != nil {
+		return errors.Join(err, f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	if f.Gzip {
+		f.compressing.Add(1)
+		go func() {
+			defer f.compressing.Done()
+			if err := gzipFile(rotated); err != nil {
+				f.mu.Lock()
+				if f.compressErr == nil {
+					f.compressErr = err
+				}
+				f.mu.Unlock()
+			}
+		}()
+	}
+	return nil
+}
+
+// rotatedName returns a name for the current file that isn't taken yet
+func (f *RotatingFile) rotatedName() string {
+	ext := filepath.Ext(f.Path)
+	base := strings.TrimSuffix(f.Path, ext)
+	stamp := f.now().UTC().Format("20060102T150405Z")
+
+	name := fmt.Sprintf("%s-%s%s", base, stamp, ext)
+	for i := 1; exists(name) || exists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s-%s-%d%s", base, stamp, i, ext)
+	}
+	return name
+}
+
+// closeFile syncs and closes the current file
+func (f *RotatingFile) closeFile() error {
+	file := f.file
+	f.file = nil
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Close syncs and closes the file, then waits for rotated files to finish
+// compressing
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.closeFile()
+	}
+	f.mu.Unlock()
+
+	f.compressing.Wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return errors.Join(err, f.compressErr)
+}
+
+// gzipFile compresses path to path.gz and removes path
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("Rotates by size", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "posts.ndjson")
+
+		f, err := OpenRotatingFile(path, 10, 0, false)
+		require.NoError(t, err)
+		for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+			_, err := f.Write([]byte(line))
+			require.NoError(t, err)
+		}
+		require.NoError(t, f.Close())
+
+		current, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "cccc\n", string(current), "Lines that would overflow go to a new file")
+
+		rotated, err := filepath.Glob(filepath.Join(dir, "posts-*.ndjson"))
+		require.NoError(t, err)
+		require.Len(t, rotated, 1)
+		data, err := os.ReadFile(rotated[0])
+		require.NoError(t, err)
+		assert.Equal(t, "aaaa\nbbbb\n", string(data))
+	})
+
+	t.Run("Rotates by age and gzips", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "posts.ndjson")
+		now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+		f, err := OpenRotatingFile(path, 0, time.Hour, true)
+		require.NoError(t, err)
+		f.now = func() time.Time { return now }
+		f.opened = now
+
+		_, err = f.Write([]byte("first\n"))
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = f.Write([]byte("second\n"))
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = f.Write([]byte("third\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		rotated, err := filepath.Glob(filepath.Join(dir, "posts-*"))
+		require.NoError(t, err)
+		sort.Strings(rotated)
+		require.Equal(t, []string{
+			filepath.Join(dir, "posts-20250102T160405Z.ndjson.gz"),
+			filepath.Join(dir, "posts-20250102T170405Z.ndjson.gz"),
+		}, rotated)
+
+		zr, err := gzip.NewReader(mustOpen(t, rotated[0]))
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, "first\n", string(data))
+
+		current, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "third\n", string(current))
+	})
+
+	t.Run("Keeps writing after a failed rotation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "posts.ndjson")
+
+		f, err := OpenRotatingFile(path, 10, 0, true)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("aaaa\nbbbb\n"))
+		require.NoError(t, err)
+
+		// The file can't be moved aside once it's gone
+		require.NoError(t, os.Remove(path))
+		_, err = f.Write([]byte("cccc\n"))
+		require.Error(t, err)
+
+		_, err = f.Write([]byte("dddd\n"))
+		require.NoError(t, err, "Path is reopened for later writes")
+		require.NoError(t, f.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "dddd\n", string(data))
+	})
+
+	t.Run("Appends to an existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "posts.ndjson")
+		require.NoError(t, os.WriteFile(path, []byte("old\n"), 0644))
+
+		f, err := OpenRotatingFile(path, 0, 0, false)
+		require.NoError(t, err)
+		_, err = f.Write([]byte("new\n"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "old\nnew\n", string(data))
+	})
+}
+
+func mustOpen(t *testing.T, path string) *os.File {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/jq"
+)
+
+// DefaultStallTimeout is how long a reconnecting stream may go without data
+// or a heartbeat before the connection is considered dead. X sends a
+// heartbeat every 20 seconds.
+const DefaultStallTimeout = 30 * time.Second
+
+// StreamOptions controls how a stream deals with stalls and disconnects
+type StreamOptions struct {
+	// Reconnect makes the stream reconnect when it drops, backing off the
+	// way X asks streaming clients to
+	Reconnect bool
+	// MaxReconnects stops reconnecting after this many attempts in a row
+	// that received nothing (0 = no limit)
+	MaxReconnects int
+	// StallTimeout drops a connection that has been silent this long (0 = never)
+	StallTimeout time.Duration
+	// BackfillMinutes asks for up to 5 minutes of posts missed while
+	// disconnected when reconnecting
+	BackfillMinutes int
+
+	// Output receives the streamed lines instead of stdout
+	Output io.Writer
+	// MaxEvents ends the stream after this many lines, heartbeats aside (0 = no limit)
+	MaxEvents int
+	// Duration ends the stream after this long (0 = no limit)
+	Duration time.Duration
+	// RotateSize and RotateEvery start a new output file once the current one
+	// reaches this many bytes or has been open this long, see RotatingFile
+	RotateSize  int64
+	RotateEvery time.Duration
+	// Gzip compresses rotated output files
+	Gzip bool
+
+	// open is a stream response already received, which is read before
+	// connecting for the first time
+	open *openStream
+}
+
+// streamSink writes the lines of a stream, across reconnects, and counts them
+type streamSink struct {
+	out       io.Writer
+	events    int
+	maxEvents int
+	err       error
+}
+
+// write writes one line, or what the query makes of it. Lines the query
+// fails on or filters out are skipped; failing to write ends the stream.
+func (s *streamSink) write(line string, query *jq.Query) {
+	data := []byte(line + "\n")
+	if query != nil {
+		filtered, err := query.Filter(json.RawMessage(line))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+			return
+		}
+		data = filtered
+	}
+	// Only lines that made it to the output count towards MaxEvents
+	if len(data) == 0 {
+		return
+	}
+	s.events++
+	if _, err := s.out.Write(data); err != nil {
+		s.err = xurlErrors.NewIOError(err)
+	}
+}
+
+// done reports whether the stream should end
+func (s *streamSink) done() bool {
+	return s.err != nil || (s.maxEvents > 0 && s.events >= s.maxEvents)
+}
+
+// connectStream sends a streaming request, printing it first as
+// options.Curl asks. A dry run returns no response.
+func (c *ApiClient) connectStream(ctx context.Context, options RequestOptions) (*http.Response, error) {
+	req, err := c.BuildRequest(options)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if options.Curl != nil {
+		if err := printCurl(req, options); err != nil {
+			return nil, xurlErrors.NewIOError(err)
+		}
+		if options.Curl.DryRun {
+			return nil, nil
+		}
+	}
+
+	if options.Verbose {
+		fmt.Printf("\033[1;34m> %s\033[0m %s\n", req.Method, req.URL)
+		for key, values := range req.Header {
+			for _, value := range values {
+				fmt.Printf("\033[1;36m> %s\033[0m: %s\n", key, value)
+			}
+		}
+		fmt.Println()
+	}
+
+	client := &http.Client{
+		Transport: c.client.Transport,
+		Timeout:   0,
+	}
+
+	fmt.Fprintf(os.Stderr, "\033[1;32mConnecting to streaming endpoint: %s\033[0m\n", options.Endpoint)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, xurlErrors.NewHTTPError(err)
+	}
+	return resp, nil
+}
+
+// Reconnect delays, following X's guidance for streaming endpoints: back
+// off linearly after network errors, exponentially after HTTP errors, and
+// exponentially from a minute after 429s
+var (
+	networkBackoffStep    = 250 * time.Millisecond
+	networkBackoffMax     = 16 * time.Second
+	httpBackoffStart      = 5 * time.Second
+	httpBackoffMax        = 320 * time.Second
+	rateLimitBackoffStart = time.Minute
+	rateLimitBackoffMax   = 16 * time.Minute
+)
+
+// streamBackoff counts failed connection attempts by kind
+type streamBackoff struct {
+	network   int
+	http      int
+	rateLimit int
+}
+
+// next returns how long to wait before reconnecting after err, or false if
+// reconnecting won't help. A nil err means the server ended the stream.
+func (b *streamBackoff) next(err error) (time.Duration, bool) {
+	var e *xurlErrors.Error
+	status := 0
+	if errors.As(err, &e) {
+		status = e.StatusCode
+	}
+
+	switch {
+	case errors.Is(err, bufio.ErrTooLong):
+		return 0, false
+	case status == http.StatusTooManyRequests:
+		b.rateLimit++
+		return exponential(rateLimitBackoffStart, rateLimitBackoffMax, b.rateLimit), true
+	case status >= 500:
+		b.http++
+		return exponential(httpBackoffStart, httpBackoffMax, b.http), true
+	case status >= 400:
+		// Bad credentials, a missing endpoint and the like won't fix themselves
+		return 0, false
+	}
+	b.network++
+	return min(networkBackoffStep*time.Duration(b.network), networkBackoffMax), true
+}
+
+// exponential returns start doubled for each attempt after the first, up to limit
+func exponential(start, limit time.Duration, attempt int) time.Duration {
+	d := start
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// StreamRequest sends an HTTP request and streams the response
+func (c *ApiClient) StreamRequest(options RequestOptions) error {
+	return c.StreamRequestContext(c.baseContext(), options)
+}
+
+// StreamRequestContext streams the response line by line until the server
+// closes the connection or ctx is cancelled, which ends the stream cleanly,
+// as do reaching options.Stream.MaxEvents or Duration. With
+// options.Stream.Reconnect it reconnects instead of returning when the
+// connection drops or stalls.
+func (c *ApiClient) StreamRequestContext(ctx context.Context, options RequestOptions) error {
+	var stream StreamOptions
+	if options.Stream != nil {
+		stream = *options.Stream
+	}
+	if stream.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stream.Duration)
+		defer cancel()
+	}
+	sink := &streamSink{out: stream.Output, maxEvents: stream.MaxEvents}
+	if sink.out == nil {
+		sink.out = os.Stdout
+	}
+
+	var backoff streamBackoff
+	failures := 0
+	for attempt := 0; ; attempt++ {
+		attemptOptions := options
+		if attempt > 0 {
+			// Only the first connection is printed or sent as a dry run
+			attemptOptions.Curl = nil
+			if stream.BackfillMinutes > 0 && !strings.Contains(options.Endpoint, "backfill_minutes=") {
+				attemptOptions.Params = append(slices.Clone(options.Params), fmt.Sprintf("backfill_minutes=%d", stream.BackfillMinutes))
+			}
+		}
+
+		received, err := c.streamOnce(ctx, attemptOptions, stream.StallTimeout, sink, stream.open)
+		stream.open = nil
+		if options.Curl != nil && options.Curl.DryRun {
+			return nil
+		}
+		if sink.err != nil {
+			return sink.err
+		}
+		if ctx.Err() != nil || sink.done() || (err == nil && !stream.Reconnect) {
+			fmt.Fprintln(os.Stderr, "\033[1;32m--- End of stream ---\033[0m")
+			return nil
+		}
+		if !stream.Reconnect {
+			return err
+		}
+
+		if received {
+			backoff = streamBackoff{}
+			failures = 0
+		}
+		delay, retry := backoff.next(err)
+		failures++
+		if !retry || (stream.MaxReconnects > 0 && failures > stream.MaxReconnects) {
+			if err == nil {
+				err = xurlErrors.NewHTTPError(fmt.Errorf("stream ended after %d reconnect attempts", failures-1))
+			}
+			return err
+		}
+
+		reason := "stream ended"
+		if err != nil {
+			reason = err.Error()
+		}
+		fmt.Fprintf(os.Stderr, "\033[33mStream disconnected (%s), reconnecting in %s\033[0m\n", reason, delay)
+		if sleepContext(ctx, delay) != nil {
+			return nil
+		}
+	}
+}
+
+// streamOnce connects once, or reads open when it is set, and writes lines
+// to sink until the connection ends or the sink is done. It reports whether
+// anything, even a heartbeat, was received.
+func (c *ApiClient) streamOnce(ctx context.Context, options RequestOptions, stallTimeout time.Duration, sink *streamSink, open *openStream) (bool, error) {
+	// Cancelling connCtx when the connection stalls unblocks the read below
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stalled atomic.Bool
+	resetStall := func() {}
+	if stallTimeout > 0 {
+		timer := time.AfterFunc(stallTimeout, func() {
+			stalled.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+		resetStall = func() { timer.Reset(stallTimeout) }
+	}
+	stallError := func(err error) error {
+		if stalled.Load() {
+			return xurlErrors.NewHTTPError(fmt.Errorf("no data or heartbeat for %s", stallTimeout))
+		}
+		return err
+	}
+
+	var resp *http.Response
+	var body io.Reader
+	if open != nil {
+		// The response was received with another context, so closing its
+		// body is what unblocks the read
+		resp, body = open.resp, open.body
+		stop := context.AfterFunc(connCtx, func() { resp.Body.Close() })
+		defer stop()
+		if open.cancel != nil {
+			defer open.cancel()
+		}
+	} else {
+		var err error
+		resp, err = c.connectStream(connCtx, options)
+		if err != nil || resp == nil {
+			return false, stallError(err)
+		}
+		body = resp.Body
+	}
+	defer resp.Body.Close()
+	req := resp.Request
+
+	rateLimit := c.recordRateLimit(req, resp)
+
+	if options.Verbose {
+		fmt.Printf("\033[1;31m< %s\033[0m\n", resp.Status)
+		for key, values := range resp.Header {
+			for _, value := range values {
+				fmt.Printf("\033[1;32m< %s\033[0m: %s\n", key, value)
+			}
+		}
+		logRateLimit(rateLimit)
+		fmt.Println()
+	}
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return false, stallError(xurlErrors.NewIOError(err))
+		}
+
+		var js json.RawMessage
+		if err := json.Unmarshal(body, &js); err != nil {
+			return false, statusError(resp)
+		}
+		return false, xurlErrors.NewResponseError(resp.StatusCode, js, rateLimit)
+	}
+
+	scanner := bufio.NewScanner(body)
+
+	const maxScanTokenSize = 1024 * 1024
+	buf := make([]byte, maxScanTokenSize)
+	scanner.Buffer(buf, maxScanTokenSize)
+
+	// Banners go to stderr so that stdout is only the stream's NDJSON
+	fmt.Fprintln(os.Stderr, "\033[1;32m--- Streaming response started ---\033[0m")
+	fmt.Fprintln(os.Stderr, "\033[1;32m--- Press Ctrl+C to stop ---\033[0m")
+
+	received := false
+	for scanner.Scan() {
+		received = true
+		resetStall()
+		line := scanner.Text()
+
+		// Blank lines are heartbeats
+		if line == "" {
+			continue
+		}
+		// We can't pretty-print streaming responses
+		sink.write(line, options.Query)
+		if sink.done() {
+			return received, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		if err == bufio.ErrTooLong {
+			return received, xurlErrors.NewIOError(fmt.Errorf("line too long (over %d bytes): %w", maxScanTokenSize, err))
+		}
+		return received, stallError(xurlErrors.NewIOError(err))
+	}
+	return received, nil
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/jq"
+)
+
+func TestStreamBackoff(t *testing.T) {
+	var b streamBackoff
+	network := xurlErrors.NewHTTPError(fmt.Errorf("connection reset"))
+	for _, want := range []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond} {
+		delay, retry := b.next(network)
+		assert.True(t, retry)
+		assert.Equal(t, want, delay, "Network errors back off linearly")
+	}
+
+	server := xurlErrors.NewResponseError(503, []byte(`{}`), nil)
+	for _, want := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second} {
+		delay, _ := b.next(server)
+		assert.Equal(t, want, delay, "HTTP errors back off exponentially")
+	}
+
+	limited := xurlErrors.NewResponseError(429, []byte(`{}`), nil)
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 16 * time.Minute} {
+		delay, _ := b.next(limited)
+		assert.Equal(t, want, delay, "429s back off exponentially from a minute, up to a limit")
+	}
+
+	_, retry := b.next(xurlErrors.NewResponseError(401, []byte(`{}`), nil))
+	assert.False(t, retry, "Auth errors won't fix themselves")
+
+	delay, retry := b.next(nil)
+	assert.True(t, retry, "Streams the server ends are reconnected")
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestStreamReconnect(t *testing.T) {
+	defer func(step time.Duration) { networkBackoffStep = step }(networkBackoffStep)
+	networkBackoffStep = time.Millisecond
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	t.Run("Reconnects with backfill", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var connections atomic.Int32
+		var backfill []string
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := connections.Add(1)
+			backfill = append(backfill, r.URL.Query().Get("backfill_minutes"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(fmt.Sprintf(`{"data":{"id":"%d"}}`, n) + "\n"))
+			if n == 3 {
+				cancel()
+			}
+		}))
+		defer stream.Close()
+
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		err := client.StreamRequestContext(ctx, RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Stream:   &StreamOptions{Reconnect: true, BackfillMinutes: 2, StallTimeout: time.Second},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), connections.Load())
+		assert.Equal(t, []string{"", "2", "2"}, backfill, "Only reconnects ask for backfill")
+	})
+
+	t.Run("Stalled connection", func(t *testing.T) {
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("\r\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer stream.Close()
+
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		start := time.Now()
+		err := client.StreamRequestContext(context.Background(), RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Stream:   &StreamOptions{StallTimeout: 100 * time.Millisecond},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no data or heartbeat for 100ms")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Gives up on errors reconnecting can't fix", func(t *testing.T) {
+		var connections atomic.Int32
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connections.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"title":"Unauthorized","status":401}`))
+		}))
+		defer stream.Close()
+
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		err := client.StreamRequestContext(context.Background(), RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Stream:   &StreamOptions{Reconnect: true},
+		})
+		require.Error(t, err)
+		assert.Equal(t, xurlErrors.ExitAuth, xurlErrors.ExitCode(err))
+		assert.Equal(t, int32(1), connections.Load())
+	})
+
+	t.Run("Stops after max reconnects", func(t *testing.T) {
+		var connections atomic.Int32
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connections.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+		}))
+		defer stream.Close()
+
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		err := client.StreamRequestContext(context.Background(), RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Stream:   &StreamOptions{Reconnect: true, MaxReconnects: 2},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stream ended after 2 reconnect attempts")
+		assert.Equal(t, int32(3), connections.Load())
+	})
+}
+
+func TestStreamStopConditions(t *testing.T) {
+	defer func(step time.Duration) { networkBackoffStep = step }(networkBackoffStep)
+	networkBackoffStep = time.Millisecond
+
+	authMock, tempDir := createMockAuth(t)
+	defer os.RemoveAll(tempDir)
+
+	t.Run("Max events across reconnects", func(t *testing.T) {
+		var connections atomic.Int32
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := connections.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(fmt.Sprintf("{\"id\":\"%d-1\"}\r\n\r\n{\"id\":\"%d-2\"}\r\n", n, n)))
+		}))
+		defer stream.Close()
+
+		var out bytes.Buffer
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		err := client.StreamRequestContext(context.Background(), RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Stream:   &StreamOptions{Reconnect: true, MaxEvents: 3, Output: &out},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "{\"id\":\"1-1\"}\n{\"id\":\"1-2\"}\n{\"id\":\"2-1\"}\n", out.String(), "Heartbeats aren't events")
+		assert.Equal(t, int32(2), connections.Load())
+	})
+
+	t.Run("Max events counts only lines the query outputs", func(t *testing.T) {
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{\"id\":\"1\",\"lang\":\"de\"}\r\n{\"id\":\"2\",\"lang\":\"en\"}\r\n{\"id\":\"3\",\"lang\":\"fr\"}\r\n{\"id\":\"4\",\"lang\":\"en\"}\r\n"))
+		}))
+		defer stream.Close()
+
+		query, err := jq.Compile(`select(.lang == "en") | .id`)
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		err = client.StreamRequestContext(context.Background(), RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Query:    query,
+			Stream:   &StreamOptions{MaxEvents: 2, Output: &out},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2\n4\n", out.String())
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{\"id\":\"1\"}\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer stream.Close()
+
+		var out bytes.Buffer
+		client := NewApiClient(&config.Config{APIBaseURL: stream.URL}, authMock)
+		start := time.Now()
+		err := client.StreamRequestContext(context.Background(), RequestOptions{
+			Method:   "GET",
+			Endpoint: "/2/tweets/search/stream",
+			Stream:   &StreamOptions{Duration: 100 * time.Millisecond, Output: &out},
+		})
+		require.NoError(t, err, "Reaching the duration ends the stream cleanly")
+		assert.Equal(t, "{\"id\":\"1\"}\n", out.String())
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
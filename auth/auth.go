@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"bufio"
 	"context"
 	"crypto/hmac"
 	"crypto/rand"
@@ -30,13 +31,16 @@ import (
 
 type Auth struct {
 	TokenStore   *store.TokenStore
+	apiBaseURL   string
 	infoURL      string
 	clientID     string
 	clientSecret string
 	authURL      string
 	tokenURL     string
+	revokeURL    string
 	redirectURI  string
 	appName      string // explicit app override (empty = use default)
+	httpClient   *http.Client
 }
 
 // NewAuth creates a new Auth object.
@@ -61,11 +65,13 @@ func NewAuth(cfg *config.Config) *Auth {
 
 	return &Auth{
 		TokenStore:   ts,
+		apiBaseURL:   cfg.APIBaseURL,
 		infoURL:      cfg.InfoURL,
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		authURL:      cfg.AuthURL,
 		tokenURL:     cfg.TokenURL,
+		revokeURL:    cfg.RevokeURL,
 		redirectURI:  cfg.RedirectURI,
 		appName:      appName,
 	}
@@ -77,6 +83,26 @@ func (a *Auth) WithTokenStore(tokenStore *store.TokenStore) *Auth {
 	return a
 }
 
+// WithHTTPClient sets the HTTP client used for token exchange, refresh and
+// user lookups, so they go through the same transport as API requests
+func (a *Auth) WithHTTPClient(client *http.Client) *Auth {
+	a.httpClient = client
+	return a
+}
+
+// HTTPClient returns the client set with WithHTTPClient, or nil
+func (a *Auth) HTTPClient() *http.Client {
+	return a.httpClient
+}
+
+// oauth2Context makes the oauth2 package use the configured HTTP client
+func (a *Auth) oauth2Context(ctx context.Context) context.Context {
+	if a.httpClient == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, a.httpClient)
+}
+
 // WithAppName sets the explicit app name override.
 func (a *Auth) WithAppName(appName string) *Auth {
 	a.appName = appName
@@ -94,13 +120,29 @@ func (a *Auth) WithAppName(appName string) *Auth {
 
 // GetOAuth1Header gets the OAuth1 header for a request
 func (a *Auth) GetOAuth1Header(method, urlStr string, additionalParams map[string]string) (string, error) {
-	token := a.TokenStore.GetOAuth1Tokens()
+	return a.GetOAuth1HeaderForUser(method, urlStr, "", additionalParams)
+}
+
+// GetOAuth1HeaderForUser gets the OAuth1 header for a request, signed for
+// the OAuth1 account of username or the default account of the app
+func (a *Auth) GetOAuth1HeaderForUser(method, urlStr, username string, additionalParams map[string]string) (string, error) {
+	token := a.TokenStore.GetOAuth1TokensForUser(a.appName, username)
 	if token == nil || token.OAuth1 == nil {
+		if username != "" {
+			return "", xurlErrors.NewAuthError("TokenNotFound", fmt.Errorf("OAuth1 token not found for %q", username))
+		}
 		return "", xurlErrors.NewAuthError("TokenNotFound", errors.New("OAuth1 token not found"))
 	}
 
 	oauth1Token := token.OAuth1
+	return oauth1Header(method, urlStr, additionalParams, oauth1Token.ConsumerKey, oauth1Token.ConsumerSecret, oauth1Token.AccessToken, oauth1Token.TokenSecret)
+}
 
+// oauth1Header signs a request with HMAC-SHA1. The token is left out when
+// it is empty, as it is when asking for a request token, and oauth_
+// parameters in additionalParams, such as oauth_callback, go into the
+// header with the others.
+func oauth1Header(method, urlStr string, additionalParams map[string]string, consumerKey, consumerSecret, token, tokenSecret string) (string, error) {
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
 		return "", xurlErrors.NewAuthError("InvalidURL", err)
@@ -117,26 +159,33 @@ func (a *Auth) GetOAuth1Header(method, urlStr string, additionalParams map[strin
 		params[key] = value
 	}
 
-	params["oauth_consumer_key"] = oauth1Token.ConsumerKey
+	params["oauth_consumer_key"] = consumerKey
 	params["oauth_nonce"] = generateNonce()
 	params["oauth_signature_method"] = "HMAC-SHA1"
 	params["oauth_timestamp"] = generateTimestamp()
-	params["oauth_token"] = oauth1Token.AccessToken
+	if token != "" {
+		params["oauth_token"] = token
+	}
 	params["oauth_version"] = "1.0"
 
-	signature, err := generateSignature(method, urlStr, params, oauth1Token.ConsumerSecret, oauth1Token.TokenSecret)
+	signature, err := generateSignature(method, urlStr, params, consumerSecret, tokenSecret)
 	if err != nil {
 		return "", xurlErrors.NewAuthError("SignatureGenerationError", err)
 	}
+	params["oauth_signature"] = signature
+
+	var keys []string
+	for key := range params {
+		if strings.HasPrefix(key, "oauth_") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
 
 	var oauthParams []string
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_consumer_key=\"%s\"", encode(oauth1Token.ConsumerKey)))
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_nonce=\"%s\"", encode(params["oauth_nonce"])))
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_signature=\"%s\"", encode(signature)))
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_signature_method=\"%s\"", encode("HMAC-SHA1")))
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_timestamp=\"%s\"", encode(params["oauth_timestamp"])))
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_token=\"%s\"", encode(oauth1Token.AccessToken)))
-	oauthParams = append(oauthParams, fmt.Sprintf("oauth_version=\"%s\"", encode("1.0")))
+	for _, key := range keys {
+		oauthParams = append(oauthParams, fmt.Sprintf("%s=\"%s\"", key, encode(params[key])))
+	}
 
 	return "OAuth " + strings.Join(oauthParams, ", "), nil
 }
@@ -164,6 +213,28 @@ func (a *Auth) GetOAuth2Header(username string) (string, error) {
 
 // OAuth2Flow starts the OAuth2 flow
 func (a *Auth) OAuth2Flow(username string) (string, error) {
+	return a.OAuth2FlowContext(context.Background(), username)
+}
+
+// OAuth2FlowContext starts the OAuth2 flow, giving up on waiting for the
+// browser callback and on the token exchange once ctx is cancelled
+func (a *Auth) OAuth2FlowContext(ctx context.Context, username string) (string, error) {
+	return a.oauth2Flow(ctx, username, a.browserCode)
+}
+
+// OAuth2FlowManualContext runs the OAuth2 flow without a browser or callback
+// listener, for remote hosts and containers: it prints the authorization URL
+// to open elsewhere and reads the URL the browser was redirected to, or just
+// its code, from in
+func (a *Auth) OAuth2FlowManualContext(ctx context.Context, username string, in io.Reader) (string, error) {
+	return a.oauth2Flow(ctx, username, func(ctx context.Context, authURL, state string) (string, error) {
+		return manualCode(ctx, authURL, state, in)
+	})
+}
+
+// oauth2Flow runs the PKCE flow, with getCode showing the user the
+// authorization URL and returning the code X redirects back with
+func (a *Auth) oauth2Flow(ctx context.Context, username string, getCode func(ctx context.Context, authURL, state string) (string, error)) (string, error) {
 	config := &oauth2.Config{
 		ClientID:     a.clientID,
 		ClientSecret: a.clientSecret,
@@ -179,7 +250,8 @@ func (a *Auth) OAuth2Flow(username string) (string, error) {
 	if _, err := rand.Read(b); err != nil {
 		return "", xurlErrors.NewAuthError("IOError", err)
 	}
-	state := base64.StdEncoding.EncodeToString(b)
+	// URL-safe, so that a pasted redirect URL can't mangle it
+	state := base64.RawURLEncoding.EncodeToString(b)
 
 	verifier, challenge := generateCodeVerifierAndChallenge()
 
@@ -187,6 +259,40 @@ func (a *Auth) OAuth2Flow(username string) (string, error) {
 		oauth2.SetAuthURLParam("code_challenge", challenge),
 		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
 
+	code, err := getCode(ctx, authURL, state)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := config.Exchange(a.oauth2Context(ctx), code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		return "", xurlErrors.NewAuthError("TokenExchangeError", err)
+	}
+
+	var usernameStr string
+	if username != "" {
+		usernameStr = username
+	} else {
+		fetchedUsername, err := a.fetchUsernameContext(ctx, token.AccessToken)
+		if err != nil {
+			return "", err
+		}
+		usernameStr = fetchedUsername
+	}
+
+	expirationTime := uint64(time.Now().Add(time.Duration(token.Expiry.Unix()-time.Now().Unix()) * time.Second).Unix())
+
+	err = a.TokenStore.SaveOAuth2Token(usernameStr, token.AccessToken, token.RefreshToken, expirationTime)
+	if err != nil {
+		return "", xurlErrors.NewAuthError("TokenStorageError", err)
+	}
+
+	return token.AccessToken, nil
+}
+
+// browserCode opens the authorization URL in a browser and waits for X to
+// redirect it to the callback listener
+func (a *Auth) browserCode(ctx context.Context, authURL, state string) (string, error) {
 	err := openBrowser(authURL)
 	if err != nil {
 		fmt.Println("Failed to open browser automatically. Please visit this URL manually:")
@@ -220,45 +326,103 @@ func (a *Auth) OAuth2Flow(username string) (string, error) {
 			fmt.Sscanf(parsedURL.Port(), "%d", &port)
 		}
 
-		if err := StartListener(port, callback); err != nil {
+		if err := StartListenerContext(ctx, port, callback); err != nil && ctx.Err() == nil {
 			fmt.Printf("Error in OAuth listener: %v\n", err)
 		}
 	}()
 
-	var code string
 	select {
-	case code = <-codeChan:
+	case code := <-codeChan:
 		if code == "" {
 			return "", xurlErrors.NewAuthError("ListenerError", errors.New("oauth2 listener failed"))
 		}
+		return code, nil
 	case <-time.After(5 * time.Minute):
 		return "", xurlErrors.NewAuthError("Timeout", errors.New("authentication timed out"))
+	case <-ctx.Done():
+		return "", xurlErrors.NewAuthError("Cancelled", ctx.Err())
 	}
+}
 
-	token, err := config.Exchange(context.Background(), code, oauth2.SetAuthURLParam("code_verifier", verifier))
+// manualCode prints the authorization URL and reads the redirected URL or
+// code from in
+func manualCode(ctx context.Context, authURL, state string, in io.Reader) (string, error) {
+	fmt.Println("Open this URL in a browser and authorize the app:")
+	fmt.Println()
+	fmt.Println(authURL)
+	fmt.Println()
+	fmt.Println("The browser is then sent to the redirect URI, which may fail to load.")
+	fmt.Print("Paste the URL from its address bar (or just the code): ")
+
+	line, err := readLine(ctx, in)
 	if err != nil {
-		return "", xurlErrors.NewAuthError("TokenExchangeError", err)
+		return "", err
 	}
+	return ParseAuthorizationResponse(line, state)
+}
 
-	var usernameStr string
-	if username != "" {
-		usernameStr = username
-	} else {
-		fetchedUsername, err := a.fetchUsername(token.AccessToken)
-		if err != nil {
-			return "", err
+// readLine reads a line from in, giving up once ctx is done
+func readLine(ctx context.Context, in io.Reader) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	lines := make(chan result, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
 		}
-		usernameStr = fetchedUsername
+		lines <- result{line, err}
+	}()
+
+	select {
+	case r := <-lines:
+		if r.err != nil {
+			return "", xurlErrors.NewAuthError("IOError", r.err)
+		}
+		return r.line, nil
+	case <-ctx.Done():
+		return "", xurlErrors.NewAuthError("Cancelled", ctx.Err())
 	}
+}
 
-	expirationTime := uint64(time.Now().Add(time.Duration(token.Expiry.Unix()-time.Now().Unix()) * time.Second).Unix())
+// ParseAuthorizationResponse returns the authorization code in a redirected
+// URL (or just its query string) after checking its state, or input itself
+// when it is a bare code
+func ParseAuthorizationResponse(input, state string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", xurlErrors.NewAuthError("InvalidCode", errors.New("empty authorization code"))
+	}
+	if !strings.ContainsAny(input, "?=&") {
+		return input, nil
+	}
 
-	err = a.TokenStore.SaveOAuth2Token(usernameStr, token.AccessToken, token.RefreshToken, expirationTime)
+	query := input
+	if i := strings.Index(input, "?"); i >= 0 {
+		query = input[i+1:]
+	}
+	query, _, _ = strings.Cut(query, "#")
+	values, err := url.ParseQuery(query)
 	if err != nil {
-		return "", xurlErrors.NewAuthError("TokenStorageError", err)
+		return "", xurlErrors.NewAuthError("InvalidCode", fmt.Errorf("could not parse the redirected URL: %w", err))
 	}
 
-	return token.AccessToken, nil
+	if denied := values.Get("error"); denied != "" {
+		if description := values.Get("error_description"); description != "" {
+			denied += ": " + description
+		}
+		return "", xurlErrors.NewAuthError("AccessDenied", fmt.Errorf("authorization failed (%s)", denied))
+	}
+	if values.Get("state") != state {
+		return "", xurlErrors.NewAuthError("InvalidState", errors.New("invalid state parameter: the URL is from a different login attempt"))
+	}
+	code := values.Get("code")
+	if code == "" {
+		return "", xurlErrors.NewAuthError("InvalidCode", errors.New("empty authorization code"))
+	}
+	return code, nil
 }
 
 // RefreshOAuth2Token validates and refreshes an OAuth2 token if needed
@@ -288,7 +452,7 @@ func (a *Auth) RefreshOAuth2Token(username string) (string, error) {
 		},
 	}
 
-	tokenSource := config.TokenSource(context.Background(), &oauth2.Token{
+	tokenSource := config.TokenSource(a.oauth2Context(context.Background()), &oauth2.Token{
 		RefreshToken: token.OAuth2.RefreshToken,
 	})
 
@@ -328,14 +492,21 @@ func (a *Auth) GetBearerTokenHeader() (string, error) {
 }
 
 func (a *Auth) fetchUsername(accessToken string) (string, error) {
-	req, err := http.NewRequest("GET", a.infoURL, nil)
+	return a.fetchUsernameContext(context.Background(), accessToken)
+}
+
+func (a *Auth) fetchUsernameContext(ctx context.Context, accessToken string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.infoURL, nil)
 	if err != nil {
 		return "", xurlErrors.NewAuthError("RequestCreationError", err)
 	}
 
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
+	client := a.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", xurlErrors.NewAuthError("NetworkError", err)
@@ -1,9 +1,17 @@
 package auth
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -246,6 +254,28 @@ func TestOAuth1HeaderWithTokenStore(t *testing.T) {
 	assert.Contains(t, header, "oauth_consumer_key")
 }
 
+func TestOAuth1HeaderWithAppName(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	tokenStore.AddApp("other", "other-id", "other-secret")
+	require.NoError(t, tokenStore.SaveOAuth1TokensForApp("", "alice", "at", "ts", "default-ck", "cs"))
+	require.NoError(t, tokenStore.SaveOAuth1TokensForApp("other", "bob", "at", "ts", "other-ck", "cs"))
+
+	a := NewAuth(&config.Config{}).WithTokenStore(tokenStore)
+	header, err := a.GetOAuth1Header("GET", "https://api.x.com/2/users/me", nil)
+	require.NoError(t, err)
+	assert.Contains(t, header, `oauth_consumer_key="default-ck"`)
+
+	a.WithAppName("other")
+	header, err = a.GetOAuth1Header("GET", "https://api.x.com/2/users/me", nil)
+	require.NoError(t, err)
+	assert.Contains(t, header, `oauth_consumer_key="other-ck"`, "--app picks the app's OAuth1 account")
+
+	_, err = a.GetOAuth1HeaderForUser("GET", "https://api.x.com/2/users/me", "alice", nil)
+	assert.Error(t, err, "Accounts of other apps aren't used")
+}
+
 func TestGetOAuth2HeaderNoToken(t *testing.T) {
 	tokenStore, tempDir := createTempTokenStore(t)
 	defer os.RemoveAll(tempDir)
@@ -264,3 +294,303 @@ func TestGetOAuth2HeaderNoToken(t *testing.T) {
 	token := tokenStore.GetOAuth2Token("nobody")
 	assert.Nil(t, token)
 }
+
+func TestParseAuthorizationResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		code  string
+		err   string
+	}{
+		{"redirected URL", "http://localhost:8080/callback?state=abc&code=xyz\n", "xyz", ""},
+		{"query string", "?code=xyz&state=abc", "xyz", ""},
+		{"bare code", "  xyz  ", "xyz", ""},
+		{"wrong state", "http://localhost:8080/callback?state=other&code=xyz", "", "invalid state parameter"},
+		{"missing state", "http://localhost:8080/callback?code=xyz", "", "invalid state parameter"},
+		{"denied", "http://localhost:8080/callback?error=access_denied&state=abc", "", "authorization failed (access_denied)"},
+		{"no code", "http://localhost:8080/callback?state=abc", "", "empty authorization code"},
+		{"empty", "\n", "", "empty authorization code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := ParseAuthorizationResponse(tt.input, "abc")
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestOAuth2ManualFlow(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	var verifier string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/oauth2/token":
+			r.ParseForm()
+			verifier = r.PostForm.Get("code_verifier")
+			if r.PostForm.Get("code") != "the-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"at","refresh_token":"rt","token_type":"bearer","expires_in":7200}`))
+		case "/2/users/me":
+			w.Write([]byte(`{"data":{"id":"1","username":"botaccount"}}`))
+		}
+	}))
+	defer server.Close()
+
+	a := NewAuth(&config.Config{
+		ClientID:    "test-id",
+		AuthURL:     "https://x.com/i/oauth2/authorize",
+		TokenURL:    server.URL + "/2/oauth2/token",
+		RedirectURI: "http://localhost:8080/callback",
+		InfoURL:     server.URL + "/2/users/me",
+	}).WithTokenStore(tokenStore)
+
+	token, err := a.oauth2Flow(context.Background(), "", func(ctx context.Context, authURL, state string) (string, error) {
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+		pasted := "http://localhost:8080/callback?state=" + url.QueryEscape(parsed.Query().Get("state")) + "&code=the-code\n"
+		return manualCode(ctx, authURL, state, strings.NewReader(pasted))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "at", token)
+	assert.NotEmpty(t, verifier, "The PKCE verifier is sent with the code")
+
+	saved := tokenStore.GetOAuth2Token("botaccount")
+	require.NotNil(t, saved)
+	assert.Equal(t, "rt", saved.OAuth2.RefreshToken)
+}
+
+func TestOAuth1PINFlow(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	// checkSignature verifies a request's HMAC-SHA1 signature and returns its
+	// oauth_* parameters
+	checkSignature := func(r *http.Request, tokenSecret string) map[string]string {
+		params := map[string]string{}
+		header := strings.TrimPrefix(r.Header.Get("Authorization"), "OAuth ")
+		for _, pair := range strings.Split(header, ", ") {
+			key, value, _ := strings.Cut(pair, "=")
+			value, err := url.QueryUnescape(strings.Trim(value, `"`))
+			require.NoError(t, err)
+			params[key] = value
+		}
+		signature := params["oauth_signature"]
+		delete(params, "oauth_signature")
+		expected, err := generateSignature(r.Method, "http://"+r.Host+r.URL.Path, params, "cs", tokenSecret)
+		require.NoError(t, err)
+		assert.Equal(t, expected, signature)
+		return params
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/request_token":
+			params := checkSignature(r, "")
+			assert.Equal(t, "oob", params["oauth_callback"])
+			assert.NotContains(t, params, "oauth_token")
+			w.Write([]byte("oauth_token=request&oauth_token_secret=request-secret&oauth_callback_confirmed=true"))
+		case "/oauth/access_token":
+			params := checkSignature(r, "request-secret")
+			if params["oauth_token"] != "request" || params["oauth_verifier"] != "1234567" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("Invalid request token"))
+				return
+			}
+			w.Write([]byte("oauth_token=at&oauth_token_secret=ts&user_id=1&screen_name=botaccount"))
+		}
+	}))
+	defer server.Close()
+
+	a := NewAuth(&config.Config{APIBaseURL: server.URL}).WithTokenStore(tokenStore)
+
+	screenName, err := a.OAuth1FlowContext(context.Background(), "ck", "cs", true, strings.NewReader("1234567\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "botaccount", screenName)
+
+	saved := tokenStore.GetOAuth1Tokens()
+	require.NotNil(t, saved)
+	assert.Equal(t, store.OAuth1Token{Username: "botaccount", AccessToken: "at", TokenSecret: "ts", ConsumerKey: "ck", ConsumerSecret: "cs"}, *saved.OAuth1)
+
+	t.Run("Saved consumer credentials are reused", func(t *testing.T) {
+		_, err := a.OAuth1FlowContext(context.Background(), "", "", true, strings.NewReader("1234567\n"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Wrong PIN", func(t *testing.T) {
+		_, err := a.OAuth1FlowContext(context.Background(), "ck", "cs", true, strings.NewReader("0000000\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Invalid request token")
+	})
+}
+
+func TestListenForCallbackPath(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	received := make(chan url.Values, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- listenForCallback(context.Background(), port, "/", func(query url.Values) error {
+			received <- query
+			return nil
+		})
+	}()
+
+	base := fmt.Sprintf("http://127.0.0.1:%d", port)
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get(base + "/favicon.ico")
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Stray requests do not end the flow")
+
+	resp, err = http.Get(base + "/?oauth_verifier=v")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, <-done)
+	assert.Equal(t, "v", (<-received).Get("oauth_verifier"))
+}
+
+func TestBearerTokenGeneration(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	current := "first"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, secret, ok := r.BasicAuth()
+		if !ok || key != "ck" || secret != "cs" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":[{"code":99,"message":"Unable to verify your credentials"}]}`))
+			return
+		}
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/oauth2/token":
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			w.Write([]byte(`{"token_type":"bearer","access_token":"` + current + `"}`))
+		case "/oauth2/invalidate_token":
+			assert.Equal(t, current, r.PostForm.Get("access_token"))
+			current = "second"
+			w.Write([]byte(`{"access_token":"first"}`))
+		}
+	}))
+	defer server.Close()
+
+	a := NewAuth(&config.Config{APIBaseURL: server.URL}).WithTokenStore(tokenStore)
+	ctx := context.Background()
+
+	_, err := a.GenerateBearerToken(ctx, "", "")
+	assert.ErrorContains(t, err, "no consumer key and secret")
+
+	_, err = a.GenerateBearerToken(ctx, "ck", "wrong")
+	assert.ErrorContains(t, err, "Unable to verify your credentials")
+
+	token, err := a.GenerateBearerToken(ctx, "ck", "cs")
+	require.NoError(t, err)
+	assert.Equal(t, "first", token)
+
+	// The consumer credentials of the app's OAuth1 account are used otherwise
+	require.NoError(t, tokenStore.SaveOAuth1Tokens("at", "ts", "ck", "cs"))
+	require.NoError(t, a.InvalidateBearerToken(ctx, "", "", token))
+
+	token, err = a.GenerateBearerToken(ctx, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "second", token)
+}
+
+func TestRevokeTokens(t *testing.T) {
+	tokenStore, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	var revoked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/2/oauth2/revoke":
+			assert.Equal(t, "test-id", r.PostForm.Get("client_id"), "Public clients send their ID")
+			if r.PostForm.Get("token") == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_request"}`))
+				return
+			}
+			revoked = append(revoked, r.PostForm.Get("token_type_hint")+" "+r.PostForm.Get("token"))
+			w.Write([]byte(`{"revoked":true}`))
+		case "/1.1/oauth/invalidate_token":
+			assert.Contains(t, r.Header.Get("Authorization"), `oauth_token="o1-at"`)
+			revoked = append(revoked, "oauth1 o1-at")
+			w.Write([]byte(`{"access_token":"o1-at"}`))
+		case "/oauth2/invalidate_token":
+			revoked = append(revoked, "bearer "+r.PostForm.Get("access_token"))
+			w.Write([]byte(`{"access_token":"b"}`))
+		}
+	}))
+	defer server.Close()
+
+	a := NewAuth(&config.Config{
+		ClientID:   "test-id",
+		APIBaseURL: server.URL,
+		RevokeURL:  server.URL + "/2/oauth2/revoke",
+	}).WithTokenStore(tokenStore)
+	ctx := context.Background()
+
+	require.NoError(t, tokenStore.SaveOAuth2Token("alice", "a-at", "a-rt", 0))
+	require.NoError(t, tokenStore.SaveOAuth2Token("bob", "bad", "", 0))
+	require.NoError(t, tokenStore.SaveBearerToken("b"))
+
+	revocations := a.RevokeOAuth2Tokens(ctx, "", "alice")
+	require.Len(t, revocations, 2)
+	assert.Equal(t, "oauth2 alice access token", revocations[0].Token)
+	assert.NoError(t, revocations[0].Err)
+	assert.NoError(t, revocations[1].Err)
+	assert.Equal(t, []string{"access_token a-at", "refresh_token a-rt"}, revoked)
+
+	t.Run("Bearer tokens without consumer credentials are skipped", func(t *testing.T) {
+		revocations := a.RevokeBearerToken(ctx, "")
+		require.Len(t, revocations, 1)
+		assert.NoError(t, revocations[0].Err)
+		assert.Equal(t, "no consumer credentials", revocations[0].Skipped)
+	})
+
+	t.Run("Bearer-only app", func(t *testing.T) {
+		tokenStore.AddApp("bearer-only", "", "")
+		require.NoError(t, tokenStore.SaveBearerTokenForApp("bearer-only", "pasted"))
+		before := len(revoked)
+
+		revocations := a.RevokeAll(ctx, "bearer-only")
+		require.Len(t, revocations, 1)
+		assert.Equal(t, "bearer token", revocations[0].Token)
+		assert.NoError(t, revocations[0].Err)
+		assert.Equal(t, "no consumer credentials", revocations[0].Skipped)
+		assert.Len(t, revoked, before, "Nothing is sent to X")
+	})
+
+	revoked = nil
+	require.NoError(t, tokenStore.SaveOAuth1TokensForApp("", "carol", "o1-at", "o1-ts", "ck", "cs"))
+	revocations = a.RevokeAll(ctx, "")
+	var failed []string
+	for _, r := range revocations {
+		if r.Err != nil {
+			failed = append(failed, r.Token)
+		}
+	}
+	assert.Len(t, revocations, 5)
+	assert.Equal(t, []string{"oauth2 bob access token"}, failed)
+	assert.Equal(t, []string{"access_token a-at", "refresh_token a-rt", "oauth1 o1-at", "bearer b"}, revoked)
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// GenerateBearerToken obtains an app-only bearer token with the
+// client_credentials grant. Without consumer credentials, the app's saved
+// ones are used. X returns the app's existing token until it is invalidated.
+func (a *Auth) GenerateBearerToken(ctx context.Context, consumerKey, consumerSecret string) (string, error) {
+	consumerKey, consumerSecret, err := a.consumerCredentials(consumerKey, consumerSecret)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := a.postForm(ctx, a.apiURL("/oauth2/token"), url.Values{"grant_type": {"client_credentials"}}, consumerKey, consumerSecret)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", xurlErrors.NewAuthError("JSONDeserializationError", err)
+	}
+	if !strings.EqualFold(response.TokenType, "bearer") || response.AccessToken == "" {
+		return "", xurlErrors.NewAuthError("TokenExchangeError", errors.New("no bearer token in the response"))
+	}
+	return response.AccessToken, nil
+}
+
+// InvalidateBearerToken revokes an app-only bearer token, so that it can no
+// longer be used and the next GenerateBearerToken returns a new one.
+// Without consumer credentials, the app's saved ones are used.
+func (a *Auth) InvalidateBearerToken(ctx context.Context, consumerKey, consumerSecret, token string) error {
+	consumerKey, consumerSecret, err := a.consumerCredentials(consumerKey, consumerSecret)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.postForm(ctx, a.apiURL("/oauth2/invalidate_token"), url.Values{"access_token": {token}}, consumerKey, consumerSecret)
+	return err
+}
+
+// consumerCredentials returns the given consumer credentials, or the app's
+// saved ones when they are missing
+func (a *Auth) consumerCredentials(consumerKey, consumerSecret string) (string, string, error) {
+	if consumerKey != "" && consumerSecret != "" {
+		return consumerKey, consumerSecret, nil
+	}
+	consumerKey, consumerSecret = a.TokenStore.GetConsumerCredentialsForApp(a.appName)
+	if consumerKey == "" || consumerSecret == "" {
+		return "", "", xurlErrors.NewAuthError("MissingConsumerKey", errors.New("no consumer key and secret are saved for the app"))
+	}
+	return consumerKey, consumerSecret, nil
+}
+
+// apiURL returns the URL of path on the API host
+func (a *Auth) apiURL(path string) string {
+	return strings.TrimSuffix(a.apiBaseURL, "/") + path
+}
+
+// postForm sends a form, with basic authentication unless username is
+// empty, and returns the body of a 200 response
+func (a *Auth) postForm(ctx context.Context, endpoint string, form url.Values, username, password string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("RequestCreationError", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+	if username != "" {
+		req.SetBasicAuth(url.QueryEscape(username), url.QueryEscape(password))
+	}
+
+	client := a.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("NetworkError", err)
+	}
+	return readOK(resp)
+}
+
+// readOK reads a response body, which is an error unless the status is 200
+func readOK(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("IOError", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, xurlErrors.NewAuthError("TokenExchangeError",
+			fmt.Errorf("%s returned %s: %s", resp.Request.URL.Path, resp.Status, strings.TrimSpace(string(body))))
+	}
+	return body, nil
+}
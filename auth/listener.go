@@ -5,24 +5,45 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
+// StartListener waits for the OAuth2 redirect on 127.0.0.1:port and hands
+// its code and state to callback
 func StartListener(port int, callback func(code, state string) error) error {
+	return StartListenerContext(context.Background(), port, callback)
+}
+
+// StartListenerContext is StartListener, shutting the server down once ctx is cancelled
+func StartListenerContext(ctx context.Context, port int, callback func(code, state string) error) error {
+	return listenForCallback(ctx, port, "/callback", func(query url.Values) error {
+		return callback(query.Get("code"), query.Get("state"))
+	})
+}
+
+// listenForCallback serves 127.0.0.1:port until a redirect to path arrives,
+// handing its query to callback. Requests for other paths, such as a
+// browser's favicon, get a 404.
+func listenForCallback(ctx context.Context, port int, path string, callback func(query url.Values) error) error {
+	mux := http.NewServeMux()
+	pattern := path
+	if strings.HasSuffix(pattern, "/") {
+		// A trailing slash would match everything below it
+		pattern += "{$}"
+	}
 	server := &http.Server{
 		Addr:    fmt.Sprintf("127.0.0.1:%d", port),
-		Handler: http.DefaultServeMux,
+		Handler: mux,
 	}
 
 	done := make(chan error, 1)
 
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		state := r.URL.Query().Get("state")
-
-		err := callback(code, state)
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		err := callback(r.URL.Query())
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprintf(w, "Error: %s", err.Error())
@@ -52,5 +73,8 @@ func StartListener(port int, callback func(code, state string) error) error {
 	case <-time.After(5 * time.Minute):
 		server.Shutdown(context.Background())
 		return xurlErrors.NewAuthError("Timeout", errors.New("timeout waiting for callback"))
+	case <-ctx.Done():
+		server.Shutdown(context.Background())
+		return xurlErrors.NewAuthError("Cancelled", ctx.Err())
 	}
 }
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// OAuth1FlowContext runs the three-legged OAuth 1.0a flow for the app with
+// the given consumer credentials and saves the user's access token under
+// their screen name, which it returns. Without consumer credentials, the
+// app's saved ones are used.
+//
+// By default the authorize page opens in a browser and X redirects back to
+// the listener on the redirect URI, which has to be one of the app's
+// callback URLs. With pin, X shows a PIN instead, which is read from in.
+func (a *Auth) OAuth1FlowContext(ctx context.Context, consumerKey, consumerSecret string, pin bool, in io.Reader) (string, error) {
+	consumerKey, consumerSecret, err := a.consumerCredentials(consumerKey, consumerSecret)
+	if err != nil {
+		return "", err
+	}
+
+	callback := "oob"
+	if !pin {
+		callback = a.redirectURI
+	}
+
+	requestToken, err := a.oauth1Post(ctx, "/oauth/request_token", map[string]string{"oauth_callback": callback},
+		consumerKey, consumerSecret, "", "")
+	if err != nil {
+		return "", err
+	}
+	token, tokenSecret := requestToken.Get("oauth_token"), requestToken.Get("oauth_token_secret")
+	if token == "" || tokenSecret == "" {
+		return "", xurlErrors.NewAuthError("RequestTokenError", errors.New("no request token in the response"))
+	}
+
+	authorizeURL := a.apiURL("/oauth/authorize?oauth_token=" + url.QueryEscape(token))
+
+	var verifier string
+	if pin {
+		verifier, err = pinVerifier(ctx, authorizeURL, in)
+	} else {
+		verifier, err = a.callbackVerifier(ctx, authorizeURL, token)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	accessToken, err := a.oauth1Post(ctx, "/oauth/access_token", map[string]string{"oauth_verifier": verifier},
+		consumerKey, consumerSecret, token, tokenSecret)
+	if err != nil {
+		return "", err
+	}
+	if accessToken.Get("oauth_token") == "" || accessToken.Get("oauth_token_secret") == "" {
+		return "", xurlErrors.NewAuthError("TokenExchangeError", errors.New("no access token in the response"))
+	}
+
+	screenName := accessToken.Get("screen_name")
+	err = a.TokenStore.SaveOAuth1TokensForApp(a.appName, screenName,
+		accessToken.Get("oauth_token"), accessToken.Get("oauth_token_secret"), consumerKey, consumerSecret)
+	if err != nil {
+		return "", xurlErrors.NewAuthError("TokenStorageError", err)
+	}
+
+	return screenName, nil
+}
+
+// oauth1Post sends a signed POST to one of the OAuth 1.0a endpoints and
+// returns its form-encoded response
+func (a *Auth) oauth1Post(ctx context.Context, path string, params map[string]string, consumerKey, consumerSecret, token, tokenSecret string) (url.Values, error) {
+	body, err := a.oauth1Send(ctx, path, params, consumerKey, consumerSecret, token, tokenSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("TokenExchangeError", fmt.Errorf("could not parse the %s response: %w", path, err))
+	}
+	return values, nil
+}
+
+// oauth1Send sends a signed POST to path on the API host and returns the
+// body of a 200 response
+func (a *Auth) oauth1Send(ctx context.Context, path string, params map[string]string, consumerKey, consumerSecret, token, tokenSecret string) ([]byte, error) {
+	endpoint := a.apiURL(path)
+
+	header, err := oauth1Header("POST", endpoint, params, consumerKey, consumerSecret, token, tokenSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("RequestCreationError", err)
+	}
+	req.Header.Add("Authorization", header)
+
+	client := a.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, xurlErrors.NewAuthError("NetworkError", err)
+	}
+	return readOK(resp)
+}
+
+// pinVerifier prints the authorize URL and reads the PIN X shows from in
+func pinVerifier(ctx context.Context, authorizeURL string, in io.Reader) (string, error) {
+	fmt.Println("Open this URL in a browser and authorize the app:")
+	fmt.Println()
+	fmt.Println(authorizeURL)
+	fmt.Println()
+	fmt.Print("Enter the PIN X shows: ")
+
+	line, err := readLine(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	verifier := strings.TrimSpace(line)
+	if verifier == "" {
+		return "", xurlErrors.NewAuthError("InvalidCode", errors.New("empty PIN"))
+	}
+	return verifier, nil
+}
+
+// callbackVerifier opens the authorize URL in a browser and waits for X to
+// redirect it to the listener with the verifier for the request token
+func (a *Auth) callbackVerifier(ctx context.Context, authorizeURL, requestToken string) (string, error) {
+	parsedURL, err := url.Parse(a.redirectURI)
+	if err != nil {
+		return "", xurlErrors.NewAuthError("InvalidURL", err)
+	}
+	port := 8080
+	if parsedURL.Port() != "" {
+		fmt.Sscanf(parsedURL.Port(), "%d", &port)
+	}
+	path := parsedURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	if err := openBrowser(authorizeURL); err != nil {
+		fmt.Println("Failed to open browser automatically. Please visit this URL manually:")
+		fmt.Println(authorizeURL)
+	}
+
+	var verifier string
+	err = listenForCallback(ctx, port, path, func(query url.Values) error {
+		if query.Get("denied") != "" {
+			return xurlErrors.NewAuthError("AccessDenied", errors.New("authorization was denied"))
+		}
+		if query.Get("oauth_token") != requestToken {
+			return xurlErrors.NewAuthError("InvalidState", errors.New("the callback is for a different request token"))
+		}
+		verifier = query.Get("oauth_verifier")
+		if verifier == "" {
+			return xurlErrors.NewAuthError("InvalidCode", errors.New("empty oauth_verifier"))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return verifier, nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Revocation is the outcome of revoking one token on X
+type Revocation struct {
+	// Token says which token it was, e.g. "oauth2 alice refresh token"
+	Token string
+	// Err is why the token couldn't be revoked, or nil when it was
+	Err error
+	// Skipped says why revoking the token wasn't tried, such as missing
+	// credentials. Such a token can still be removed.
+	Skipped string
+}
+
+// RevokeOAuth2Tokens revokes the access and refresh tokens of an OAuth2 user
+// of the named app, or of all its users when username is empty
+func (a *Auth) RevokeOAuth2Tokens(ctx context.Context, appName, username string) []Revocation {
+	app := a.TokenStore.ResolveApp(appName)
+	usernames := []string{username}
+	if username == "" {
+		usernames = a.TokenStore.GetOAuth2UsernamesForApp(appName)
+	}
+
+	clientID, clientSecret := app.ClientID, app.ClientSecret
+	if clientID == "" {
+		clientID, clientSecret = a.clientID, a.clientSecret
+	}
+
+	var revocations []Revocation
+	for _, username := range usernames {
+		token, ok := app.OAuth2Tokens[username]
+		if !ok || token.OAuth2 == nil {
+			continue
+		}
+		for _, t := range []struct{ name, hint, value string }{
+			{"access token", "access_token", token.OAuth2.AccessToken},
+			{"refresh token", "refresh_token", token.OAuth2.RefreshToken},
+		} {
+			if t.value == "" {
+				continue
+			}
+			revocations = append(revocations, Revocation{
+				Token: fmt.Sprintf("oauth2 %s %s", username, t.name),
+				Err:   a.revokeOAuth2Token(ctx, t.value, t.hint, clientID, clientSecret),
+			})
+		}
+	}
+	return revocations
+}
+
+// RevokeOAuth1Tokens invalidates the access token of an OAuth1 account of
+// the named app, or of all its accounts when username is empty
+func (a *Auth) RevokeOAuth1Tokens(ctx context.Context, appName, username string) []Revocation {
+	app := a.TokenStore.ResolveApp(appName)
+	usernames := []string{username}
+	if username == "" {
+		usernames = a.TokenStore.GetOAuth1UsernamesForApp(appName)
+	}
+
+	var revocations []Revocation
+	for _, username := range usernames {
+		token, ok := app.OAuth1Tokens[username]
+		if !ok || token.OAuth1 == nil {
+			continue
+		}
+		name := username
+		if name == "" {
+			name = "(unnamed)"
+		}
+		oauth1 := token.OAuth1
+		_, err := a.oauth1Send(ctx, "/1.1/oauth/invalidate_token", nil,
+			oauth1.ConsumerKey, oauth1.ConsumerSecret, oauth1.AccessToken, oauth1.TokenSecret)
+		revocations = append(revocations, Revocation{Token: "oauth1 " + name + " access token", Err: err})
+	}
+	return revocations
+}
+
+// RevokeBearerToken invalidates the bearer token of the named app, which
+// needs the app's consumer key and secret; without them it is skipped
+func (a *Auth) RevokeBearerToken(ctx context.Context, appName string) []Revocation {
+	token := a.TokenStore.GetBearerTokenForApp(appName)
+	if token == nil {
+		return nil
+	}
+
+	revocation := Revocation{Token: "bearer token"}
+	consumerKey, consumerSecret := a.TokenStore.GetConsumerCredentialsForApp(appName)
+	if consumerKey == "" || consumerSecret == "" {
+		// A pasted bearer token usually comes without them
+		revocation.Skipped = "no consumer credentials"
+	} else {
+		revocation.Err = a.InvalidateBearerToken(ctx, consumerKey, consumerSecret, token.Bearer)
+	}
+	return []Revocation{revocation}
+}
+
+// RevokeAll revokes every token of the named app: its OAuth2 users' tokens,
+// its OAuth1 accounts' tokens and its bearer token
+func (a *Auth) RevokeAll(ctx context.Context, appName string) []Revocation {
+	var revocations []Revocation
+	revocations = append(revocations, a.RevokeOAuth2Tokens(ctx, appName, "")...)
+	revocations = append(revocations, a.RevokeOAuth1Tokens(ctx, appName, "")...)
+	revocations = append(revocations, a.RevokeBearerToken(ctx, appName)...)
+	return revocations
+}
+
+// revokeOAuth2Token revokes an OAuth2 access or refresh token. Confidential
+// clients authenticate with their secret; public clients send their ID.
+func (a *Auth) revokeOAuth2Token(ctx context.Context, token, hint, clientID, clientSecret string) error {
+	form := url.Values{"token": {token}, "token_type_hint": {hint}}
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+		clientID = ""
+	}
+	_, err := a.postForm(ctx, a.revokeURL, form, clientID, clientSecret)
+	return err
+}
@@ -0,0 +1,315 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces scrubbed header values and tokens
+const Redacted = "REDACTED"
+
+// maxStoredBody is the largest request body kept in a cassette. Larger
+// bodies, such as media uploads, still take part in matching, but are hashed
+// as they are sent rather than normalized.
+const maxStoredBody = 64 * 1024
+
+// Interaction is a recorded request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the scrubbed request of an interaction
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"headers,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is the scrubbed response of an interaction. Streamed responses
+// keep their body as Lines so they can be replayed line by line.
+type Response struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Lines      []string    `json:"lines,omitempty"`
+}
+
+// sensitiveHeaders are replaced with Redacted in cassettes
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields are query parameters, form fields and JSON keys that hold
+// credentials. They are replaced with Redacted and ignored when matching.
+var sensitiveFields = map[string]bool{
+	"access_token":       true,
+	"refresh_token":      true,
+	"token":              true,
+	"bearer_token":       true,
+	"oauth_token":        true,
+	"oauth_token_secret": true,
+	"oauth_verifier":     true,
+	"client_secret":      true,
+	"consumer_secret":    true,
+	"code_verifier":      true,
+}
+
+// Key identifies the requests that match each other: the method, the path
+// and the normalized query and body, without credentials
+func Key(method string, u *url.URL, contentType string, body []byte) string {
+	k := newKeyHash(method, u, contentType)
+	k.Write(body)
+	return k.Sum()
+}
+
+// keyHash computes a Key from a body written to it as it is sent. Bodies up
+// to maxStoredBody are kept and normalized; larger ones are hashed as they
+// go by, with only their multipart boundary replaced.
+type keyHash struct {
+	prefix      string
+	contentType string
+	// body holds the body while it fits in maxStoredBody
+	body []byte
+	size int
+	hash hash.Hash
+	// large writes to hash once the body has outgrown maxStoredBody
+	large *replacingWriter
+}
+
+func newKeyHash(method string, u *url.URL, contentType string) *keyHash {
+	query := scrubValues(u.Query(), true).Encode()
+	return &keyHash{
+		prefix:      strings.ToUpper(method) + " " + u.Path + "?" + query + "\n",
+		contentType: contentType,
+	}
+}
+
+func (k *keyHash) Write(p []byte) (int, error) {
+	k.size += len(p)
+	if k.large == nil && k.size <= maxStoredBody {
+		k.body = append(k.body, p...)
+		return len(p), nil
+	}
+	if k.large == nil {
+		k.hash = sha256.New()
+		io.WriteString(k.hash, k.prefix)
+		k.large = newReplacingWriter(k.hash, multipartBoundary(k.contentType), "BOUNDARY")
+		k.large.Write(k.body)
+		k.body = nil
+	}
+	k.large.Write(p)
+	return len(p), nil
+}
+
+// Sum returns the key of the body written so far
+func (k *keyHash) Sum() string {
+	if k.large == nil {
+		sum := sha256.Sum256([]byte(k.prefix + string(normalizeBody(k.contentType, k.body))))
+		return hex.EncodeToString(sum[:])[:12]
+	}
+	k.large.Flush()
+	return hex.EncodeToString(k.hash.Sum(nil))[:12]
+}
+
+// replacingWriter replaces every occurrence of old in what is written
+// through it, including occurrences split across writes
+type replacingWriter struct {
+	w        io.Writer
+	old, new []byte
+	pending  []byte
+}
+
+func newReplacingWriter(w io.Writer, old, new string) *replacingWriter {
+	return &replacingWriter{w: w, old: []byte(old), new: []byte(new)}
+}
+
+func (r *replacingWriter) Write(p []byte) (int, error) {
+	if len(r.old) == 0 {
+		return r.w.Write(p)
+	}
+	data := append(r.pending, p...)
+	for {
+		i := bytes.Index(data, r.old)
+		if i < 0 {
+			break
+		}
+		r.w.Write(data[:i])
+		r.w.Write(r.new)
+		data = data[i+len(r.old):]
+	}
+	// Hold back a tail that could be the start of the next occurrence
+	keep := min(len(data), len(r.old)-1)
+	r.w.Write(data[:len(data)-keep])
+	r.pending = append([]byte(nil), data[len(data)-keep:]...)
+	return len(p), nil
+}
+
+// Flush writes what has been held back
+func (r *replacingWriter) Flush() {
+	r.w.Write(r.pending)
+	r.pending = nil
+}
+
+// multipartBoundary returns the boundary of a multipart content type, or ""
+func multipartBoundary(contentType string) string {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return ""
+	}
+	return params["boundary"]
+}
+
+var slugPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// fileName is the cassette file holding the interactions for a key
+func fileName(method string, u *url.URL, key string) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(u.Path, "_"), "_")
+	if len(slug) > 60 {
+		slug = slug[:60]
+	}
+	return fmt.Sprintf("%s_%s_%s.json", strings.ToUpper(method), slug, key)
+}
+
+// Load reads the interactions stored in a cassette file
+func Load(path string) ([]Interaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %v", path, err)
+	}
+	return interactions, nil
+}
+
+// save writes interactions to a cassette file
+func save(path string, interactions []Interaction) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// normalizeBody makes equivalent bodies compare equal: JSON is re-encoded
+// with sorted keys, forms are sorted and multipart boundaries are replaced.
+// Credentials are dropped from all of them.
+func normalizeBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return []byte(scrubValues(values, true).Encode())
+		}
+	case multipartBoundary(contentType) != "":
+		return bytes.ReplaceAll(body, []byte(multipartBoundary(contentType)), []byte("BOUNDARY"))
+	}
+
+	var value any
+	if json.Unmarshal(body, &value) == nil {
+		normalized, err := json.Marshal(scrubJSON(value, true))
+		if err == nil {
+			return normalized
+		}
+	}
+	return body
+}
+
+// scrubBody replaces credentials in a JSON or form body
+func scrubBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return scrubValues(values, false).Encode()
+		}
+	}
+
+	var value any
+	if json.Unmarshal(body, &value) == nil {
+		scrubbed, err := json.Marshal(scrubJSON(value, false))
+		if err == nil {
+			return string(scrubbed)
+		}
+	}
+	return string(body)
+}
+
+// scrubValues redacts, or with drop removes, credential values
+func scrubValues(values url.Values, drop bool) url.Values {
+	scrubbed := url.Values{}
+	for key, vals := range values {
+		if sensitiveFields[key] {
+			if drop {
+				continue
+			}
+			vals = []string{Redacted}
+		}
+		scrubbed[key] = vals
+	}
+	return scrubbed
+}
+
+// scrubJSON redacts, or with drop removes, credential fields at any depth
+func scrubJSON(value any, drop bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if sensitiveFields[key] {
+				if drop {
+					delete(v, key)
+				} else {
+					v[key] = Redacted
+				}
+				continue
+			}
+			v[key] = scrubJSON(child, drop)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = scrubJSON(child, drop)
+		}
+	}
+	return value
+}
+
+// scrubHeader copies a header with sensitive values redacted
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, Redacted)
+		}
+	}
+	return scrubbed
+}
+
+// scrubURL redacts credentials in a URL's query
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	if u.RawQuery != "" {
+		scrubbed.RawQuery = scrubValues(u.Query(), false).Encode()
+	}
+	return scrubbed.String()
+}
@@ -0,0 +1,173 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	u1, _ := url.Parse("https://api.x.com/2/tweets?b=2&a=1&access_token=one")
+	u2, _ := url.Parse("http://localhost:8080/2/tweets?a=1&b=2&access_token=two")
+	assert.Equal(t,
+		Key("POST", u1, "application/json", []byte(`{"text":"hi","reply":{"id":"1"}}`)),
+		Key("post", u2, "application/json", []byte(`{"reply": {"id": "1"}, "text": "hi"}`)),
+		"Host, key order and credentials don't matter")
+
+	assert.NotEqual(t,
+		Key("POST", u1, "application/json", []byte(`{"text":"hi"}`)),
+		Key("POST", u1, "application/json", []byte(`{"text":"bye"}`)))
+
+	assert.Equal(t,
+		Key("POST", u1, "multipart/form-data; boundary=one", []byte("--one\r\nfield\r\n--one--")),
+		Key("POST", u1, "multipart/form-data; boundary=two", []byte("--two\r\nfield\r\n--two--")))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"data":{"echo":` + string(body) + `},"access_token":"abc"}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	recorder := &http.Client{Transport: NewRecorder(dir, http.DefaultTransport)}
+	req, _ := http.NewRequest("POST", server.URL+"/2/tweets", strings.NewReader(`{"text":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := recorder.Do(req)
+	require.NoError(t, err)
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "POST_2_tweets_*.json"))
+	require.Len(t, files, 1)
+	data, _ := os.ReadFile(files[0])
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), `"abc"`)
+
+	replayer := &http.Client{Transport: NewReplayer(dir)}
+	req, _ = http.NewRequest("POST", "https://api.x.com/2/tweets", strings.NewReader(`{ "text": "hi" }`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = replayer.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.JSONEq(t, `{"data":{"echo":{"text":"hi"}},"access_token":"REDACTED"}`, string(body))
+
+	req, _ = http.NewRequest("POST", "https://api.x.com/2/tweets", strings.NewReader(`{"text":"other"}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, err = replayer.Do(req)
+	assert.ErrorContains(t, err, "no recorded response for POST /2/tweets")
+}
+
+func TestReplayStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		for _, line := range []string{`{"data":{"id":"1"}}`, "", `{"data":{"id":"2"}}`} {
+			w.Write([]byte(line + "\r\n"))
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	recorder := &http.Client{Transport: NewRecorder(dir, nil)}
+	resp, err := recorder.Get(server.URL + "/2/tweets/search/stream")
+	require.NoError(t, err)
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	replayer := &http.Client{Transport: NewReplayer(dir)}
+	resp, err = replayer.Get("https://api.x.com/2/tweets/search/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	buf := make([]byte, 1024)
+	var reads []string
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			reads = append(reads, string(buf[:n]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, []string{"{\"data\":{\"id\":\"1\"}}\r\n", "\r\n", "{\"data\":{\"id\":\"2\"}}\r\n"}, reads)
+}
+
+func TestReplayRepeatedRequests(t *testing.T) {
+	dir := t.TempDir()
+	u, _ := url.Parse("https://api.x.com/2/media/upload?command=STATUS")
+	path := filepath.Join(dir, fileName("GET", u, Key("GET", u, "", nil)))
+	require.NoError(t, save(path, []Interaction{
+		{Response: Response{StatusCode: 200, Body: `{"state":"in_progress"}`}},
+		{Response: Response{StatusCode: 200, Body: `{"state":"succeeded"}`}},
+	}))
+
+	replayer := &http.Client{Transport: NewReplayer(dir)}
+	var states []string
+	for range 3 {
+		resp, err := replayer.Get(u.String())
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		states = append(states, string(body))
+	}
+	assert.Equal(t, []string{`{"state":"in_progress"}`, `{"state":"succeeded"}`, `{"state":"succeeded"}`}, states)
+}
+
+func TestRecordLargeBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"data":{"id":"1"}}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	multipartBody := func(boundary string) string {
+		return "--" + boundary + "\r\n" + strings.Repeat("x", maxStoredBody) + "\r\n--" + boundary + "--\r\n"
+	}
+
+	// A pipe has no length, like a streamed multipart body, and small writes
+	// split the boundary
+	recorder := &http.Client{Transport: NewRecorder(dir, nil)}
+	pr, pw := io.Pipe()
+	go func() {
+		body := multipartBody("one")
+		for i := 0; i < len(body); i += 2 {
+			io.WriteString(pw, body[i:min(i+2, len(body))])
+		}
+		pw.Close()
+	}()
+	req, _ := http.NewRequest("POST", server.URL+"/2/media/upload", pr)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=one")
+	resp, err := recorder.Do(req)
+	require.NoError(t, err)
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "POST_2_media_upload_*.json"))
+	require.Len(t, files, 1)
+	interactions, err := Load(files[0])
+	require.NoError(t, err)
+	assert.Regexp(t, `^<\d+ bytes not stored>$`, interactions[0].Request.Body)
+
+	replayer := &http.Client{Transport: NewReplayer(dir)}
+	req, _ = http.NewRequest("POST", "https://api.x.com/2/media/upload", strings.NewReader(multipartBody("two")))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=two")
+	resp, err = replayer.Do(req)
+	require.NoError(t, err, "The boundary doesn't take part in matching")
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"data":{"id":"1"}}`, string(body))
+}
@@ -0,0 +1,226 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper that sends requests through Transport and
+// saves every scrubbed request/response pair in Dir. Responses are saved once
+// their body has been read and closed, so streams are recorded up to the
+// point they were stopped.
+type Recorder struct {
+	Dir       string
+	Transport http.RoundTripper
+
+	mu      sync.Mutex
+	written map[string][]Interaction
+}
+
+// NewRecorder creates a Recorder that saves cassettes in dir
+func NewRecorder(dir string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{Dir: dir, Transport: transport, written: make(map[string][]Interaction)}
+}
+
+// RoundTrip sends a request and records it along with its response. The
+// request body is recorded as it is sent, so large uploads aren't buffered.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	contentType := req.Header.Get("Content-Type")
+	reqBody := &recordingRequestBody{key: newKeyHash(req.Method, req.URL, contentType)}
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		reqBody.ReadCloser = req.Body
+		req.Body = reqBody
+	}
+
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    scrubURL(req.URL),
+			Header: scrubHeader(req.Header),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     scrubHeader(resp.Header),
+		},
+	}
+	streamed := resp.ContentLength < 0
+
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(data []byte) error {
+			key, size, body := reqBody.sum()
+			interaction.Request.Body = scrubBody(contentType, body)
+			if size > maxStoredBody {
+				interaction.Request.Body = fmt.Sprintf("<%d bytes not stored>", size)
+			}
+			if streamed && bytes.Count(data, []byte("\n")) > 1 {
+				interaction.Response.Lines = splitLines(data)
+			} else {
+				interaction.Response.Body = scrubBody(resp.Header.Get("Content-Type"), data)
+			}
+			return r.save(filepath.Join(r.Dir, fileName(req.Method, req.URL, key)), interaction)
+		},
+	}
+	return resp, nil
+}
+
+// save appends an interaction to its cassette. Cassettes left over from an
+// earlier recording are replaced rather than added to.
+func (r *Recorder) save(path string, interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interactions := append(r.written[path], interaction)
+	r.written[path] = interactions
+	return save(path, interactions)
+}
+
+// recordingBody keeps a copy of everything read from a response body and
+// hands it to done when the body is closed
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	done func(data []byte) error
+	once sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		if saveErr := b.done(b.buf.Bytes()); saveErr != nil && err == nil {
+			err = fmt.Errorf("error saving cassette: %v", saveErr)
+		}
+	})
+	return err
+}
+
+// recordingRequestBody hashes a request body for its key as the transport
+// reads it, keeping the body itself only while it fits in maxStoredBody
+type recordingRequestBody struct {
+	io.ReadCloser
+	mu  sync.Mutex
+	key *keyHash
+}
+
+func (b *recordingRequestBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.key.Write(p[:n])
+	b.mu.Unlock()
+	return n, err
+}
+
+// sum returns the key of the body read so far, its size and, unless it was
+// too large to keep, the body
+func (b *recordingRequestBody) sum() (string, int, []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.key.Sum(), b.key.size, b.key.body
+}
+
+// Replayer is an http.RoundTripper that answers requests from the cassettes
+// in Dir without touching the network. Repeated requests get the recorded
+// responses in order, and the last one once they run out.
+type Replayer struct {
+	Dir string
+
+	mu     sync.Mutex
+	served map[string]int
+}
+
+// NewReplayer creates a Replayer that reads cassettes from dir
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{Dir: dir, served: make(map[string]int)}
+}
+
+// RoundTrip answers a request with its recorded response
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	k := newKeyHash(req.Method, req.URL, req.Header.Get("Content-Type"))
+	if req.Body != nil {
+		_, err := io.Copy(k, req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key := k.Sum()
+	path := filepath.Join(r.Dir, fileName(req.Method, req.URL, key))
+	interactions, err := Load(path)
+	if err != nil || len(interactions) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, req.URL.Path, r.Dir)
+	}
+
+	r.mu.Lock()
+	index := min(r.served[path], len(interactions)-1)
+	r.served[path]++
+	r.mu.Unlock()
+
+	recorded := interactions[index].Response
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode: recorded.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     recorded.Header.Clone(),
+		Request:    req,
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	if recorded.Lines != nil {
+		resp.ContentLength = -1
+		resp.Body = io.NopCloser(&lineReader{lines: recorded.Lines})
+	} else {
+		resp.ContentLength = int64(len(recorded.Body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(recorded.Body)))
+		resp.Body = io.NopCloser(strings.NewReader(recorded.Body))
+	}
+	return resp, nil
+}
+
+// lineReader returns at most one line per Read, like a stream delivering
+// one event at a time
+type lineReader struct {
+	lines   []string
+	current []byte
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	if len(l.current) == 0 {
+		if len(l.lines) == 0 {
+			return 0, io.EOF
+		}
+		l.current = []byte(l.lines[0] + "\n")
+		l.lines = l.lines[1:]
+	}
+	n := copy(p, l.current)
+	l.current = l.current[n:]
+	return n, nil
+}
+
+// splitLines splits a streamed body into its lines, without a trailing empty line
+func splitLines(data []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
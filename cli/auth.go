@@ -1,12 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/xdevplatform/xurl/auth"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 	"github.com/xdevplatform/xurl/store"
 )
 
@@ -31,23 +33,82 @@ func CreateAuthCommand(a *auth.Auth) *cobra.Command {
 // ─── auth bearer ────────────────────────────────────────────────────
 
 func createAuthBearerCmd(a *auth.Auth) *cobra.Command {
-	var bearerToken string
+	var bearerToken, consumerKey, consumerSecret string
+	var generate, invalidate bool
 
 	cmd := &cobra.Command{
 		Use:   "app",
 		Short: "Configure app-auth (bearer token)",
+		Long: `Save the bearer token used for app authentication.
+
+Paste a token from the developer portal with --bearer-token, or have xurl
+obtain one with --generate, from the app's API key and secret. They are
+saved with the app once given, and otherwise taken from its OAuth1 account.
+--invalidate revokes the saved token on X and removes it; together with
+--generate it replaces a token that may have leaked.
+
+Examples:
+  xurl auth app --bearer-token TOKEN
+  xurl auth app --generate --consumer-key KEY --consumer-secret SECRET
+  xurl auth app --invalidate
+  xurl auth app --invalidate --generate`,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := a.TokenStore.SaveBearerToken(bearerToken)
-			if err != nil {
-				fmt.Println("Error saving bearer token:", err)
-				os.Exit(1)
+			if bearerToken != "" {
+				err := a.TokenStore.SaveBearerToken(bearerToken)
+				if err != nil {
+					fmt.Println("Error saving bearer token:", err)
+					os.Exit(1)
+				}
+				fmt.Printf("\033[32mApp authentication successful!\033[0m\n")
+				return
+			}
+
+			appName, _ := cmd.Flags().GetString("app")
+			fail := func(err error) {
+				fmt.Printf("\033[31mError: %v\033[0m\n", err)
+				os.Exit(xurlErrors.ExitCode(err))
+			}
+
+			if invalidate {
+				token := a.TokenStore.GetBearerTokenForApp(appName)
+				if token == nil {
+					fail(xurlErrors.NewAuthError("TokenNotFound", errors.New("no bearer token is saved for the app")))
+				}
+				if err := a.InvalidateBearerToken(cmd.Context(), consumerKey, consumerSecret, token.Bearer); err != nil {
+					fail(err)
+				}
+				if err := a.TokenStore.ClearBearerTokenForApp(appName); err != nil {
+					fail(err)
+				}
+				fmt.Printf("\033[32mBearer token invalidated\033[0m\n")
+			}
+
+			if generate {
+				token, err := a.GenerateBearerToken(cmd.Context(), consumerKey, consumerSecret)
+				if err != nil {
+					fail(err)
+				}
+				if consumerKey != "" && consumerSecret != "" {
+					if err := a.TokenStore.SetConsumerCredentialsForApp(appName, consumerKey, consumerSecret); err != nil {
+						fail(err)
+					}
+				}
+				if err := a.TokenStore.SaveBearerTokenForApp(appName, token); err != nil {
+					fail(err)
+				}
+				fmt.Printf("\033[32mBearer token generated! App authentication successful!\033[0m\n")
 			}
-			fmt.Printf("\033[32mApp authentication successful!\033[0m\n")
 		},
 	}
 
 	cmd.Flags().StringVar(&bearerToken, "bearer-token", "", "Bearer token for app authentication")
-	cmd.MarkFlagRequired("bearer-token")
+	cmd.Flags().BoolVar(&generate, "generate", false, "Obtain a bearer token with the app's API key and secret")
+	cmd.Flags().BoolVar(&invalidate, "invalidate", false, "Revoke the saved bearer token and remove it")
+	cmd.Flags().StringVar(&consumerKey, "consumer-key", "", "The app's API key, for --generate and --invalidate")
+	cmd.Flags().StringVar(&consumerSecret, "consumer-secret", "", "The app's API secret, for --generate and --invalidate")
+	cmd.MarkFlagsOneRequired("bearer-token", "generate", "invalidate")
+	cmd.MarkFlagsMutuallyExclusive("bearer-token", "generate")
+	cmd.MarkFlagsMutuallyExclusive("bearer-token", "invalidate")
 
 	return cmd
 }
@@ -55,11 +116,24 @@ func createAuthBearerCmd(a *auth.Auth) *cobra.Command {
 // ─── auth oauth2 ────────────────────────────────────────────────────
 
 func createAuthOAuth2Cmd(a *auth.Auth) *cobra.Command {
+	var noBrowser bool
+
 	cmd := &cobra.Command{
 		Use:   "oauth2",
 		Short: "Configure OAuth2 authentication",
+		Long: `Log in with OAuth 2.0 (PKCE) and save the user's tokens.
+
+By default the authorization page opens in a browser and a listener on the
+redirect URI's port receives the result. With --no-browser, for SSH sessions
+and containers, the URL is printed instead: open it on any machine, then paste
+the URL the browser was redirected to (or just its code parameter).`,
 		Run: func(cmd *cobra.Command, args []string) {
-			_, err := a.OAuth2Flow("")
+			var err error
+			if noBrowser {
+				_, err = a.OAuth2FlowManualContext(cmd.Context(), "", cmd.InOrStdin())
+			} else {
+				_, err = a.OAuth2FlowContext(cmd.Context(), "")
+			}
 			if err != nil {
 				fmt.Println("OAuth2 authentication failed:", err)
 				os.Exit(1)
@@ -68,36 +142,71 @@ func createAuthOAuth2Cmd(a *auth.Auth) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&noBrowser, "no-browser", false, "Print the authorization URL and read the redirected URL from stdin")
+
 	return cmd
 }
 
 // ─── auth oauth1 ────────────────────────────────────────────────────
 
 func createAuthOAuth1Cmd(a *auth.Auth) *cobra.Command {
-	var consumerKey, consumerSecret, accessToken, tokenSecret string
+	var consumerKey, consumerSecret, accessToken, tokenSecret, username string
+	var pin bool
 
 	cmd := &cobra.Command{
 		Use:   "oauth1",
 		Short: "Configure OAuth1 authentication",
+		Long: `Log in with OAuth 1.0a and save the user's tokens under their screen name.
+
+The app's consumer key and secret are needed once; later logins reuse the
+saved ones. By default the authorize page opens in a browser and X redirects
+back to the redirect URI (which must be one of the app's callback URLs). With
+--pin, X shows a PIN to type in instead, which works over SSH and in
+containers.
+
+Tokens from the developer portal can be saved as they are with
+--access-token and --token-secret, under the screen name given with
+--username.
+
+Each app can hold several OAuth1 accounts; the first one becomes the
+default, and requests pick another with --auth oauth1 -u NAME.
+
+Examples:
+  xurl auth oauth1 --consumer-key KEY --consumer-secret SECRET
+  xurl auth oauth1 --pin
+  xurl auth oauth1 --consumer-key KEY --consumer-secret SECRET --access-token TOKEN --token-secret SECRET --username alice`,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := a.TokenStore.SaveOAuth1Tokens(accessToken, tokenSecret, consumerKey, consumerSecret)
+			if accessToken != "" || tokenSecret != "" {
+				if consumerKey == "" || consumerSecret == "" || accessToken == "" || tokenSecret == "" {
+					fmt.Printf("\033[31mError: --access-token and --token-secret need --consumer-key and --consumer-secret, and each other\033[0m\n")
+					os.Exit(xurlErrors.ExitUsage)
+				}
+				// Saved in the app the login flow would use
+				appName, _ := cmd.Flags().GetString("app")
+				err := a.TokenStore.SaveOAuth1TokensForApp(appName, username, accessToken, tokenSecret, consumerKey, consumerSecret)
+				if err != nil {
+					fmt.Println("Error saving OAuth1 tokens:", err)
+					os.Exit(1)
+				}
+				fmt.Printf("\033[32mOAuth1 credentials saved successfully!\033[0m\n")
+				return
+			}
+
+			screenName, err := a.OAuth1FlowContext(cmd.Context(), consumerKey, consumerSecret, pin, cmd.InOrStdin())
 			if err != nil {
-				fmt.Println("Error saving OAuth1 tokens:", err)
+				fmt.Println("OAuth1 authentication failed:", err)
 				os.Exit(1)
 			}
-			fmt.Printf("\033[32mOAuth1 credentials saved successfully!\033[0m\n")
+			fmt.Printf("\033[32mOAuth1 authentication successful! Logged in as @%s\033[0m\n", screenName)
 		},
 	}
 
 	cmd.Flags().StringVar(&consumerKey, "consumer-key", "", "Consumer key for OAuth1")
 	cmd.Flags().StringVar(&consumerSecret, "consumer-secret", "", "Consumer secret for OAuth1")
-	cmd.Flags().StringVar(&accessToken, "access-token", "", "Access token for OAuth1")
-	cmd.Flags().StringVar(&tokenSecret, "token-secret", "", "Token secret for OAuth1")
-
-	cmd.MarkFlagRequired("consumer-key")
-	cmd.MarkFlagRequired("consumer-secret")
-	cmd.MarkFlagRequired("access-token")
-	cmd.MarkFlagRequired("token-secret")
+	cmd.Flags().StringVar(&accessToken, "access-token", "", "Access token for OAuth1, to save instead of logging in")
+	cmd.Flags().StringVar(&tokenSecret, "token-secret", "", "Token secret for OAuth1, to save instead of logging in")
+	cmd.Flags().StringVar(&username, "username", "", "Screen name to save --access-token under")
+	cmd.Flags().BoolVar(&pin, "pin", false, "Authorize with a PIN instead of the callback listener")
 
 	return cmd
 }
@@ -147,9 +256,20 @@ func createAuthStatusCmd() *cobra.Command {
 					fmt.Println("      oauth2: (none)")
 				}
 
-				// OAuth1
-				if app.OAuth1Token != nil {
-					fmt.Println("      oauth1: ✓")
+				// OAuth1 accounts
+				oauth1Users := ts.GetOAuth1UsernamesForApp(name)
+				if len(oauth1Users) > 0 {
+					for _, u := range oauth1Users {
+						label := u
+						if label == "" {
+							label = "(unnamed)"
+						}
+						if u == app.DefaultOAuth1User {
+							fmt.Printf("    ▸ oauth1: %s\n", label)
+						} else {
+							fmt.Printf("      oauth1: %s\n", label)
+						}
+					}
 				} else {
 					fmt.Println("      oauth1: –")
 				}
@@ -174,36 +294,62 @@ func createAuthStatusCmd() *cobra.Command {
 // ─── auth clear ─────────────────────────────────────────────────────
 
 func createAuthClearCmd(a *auth.Auth) *cobra.Command {
-	var all, oauth1, bearer bool
-	var oauth2Username string
+	var all, oauth1, bearer, localOnly bool
+	var oauth1Username, oauth2Username string
 
 	cmd := &cobra.Command{
 		Use:   "clear",
 		Short: "Clear authentication tokens",
+		Long: `Clear authentication tokens, revoking them on X first so that copies of
+them stop working too: OAuth2 access and refresh tokens are revoked, and
+OAuth1 access tokens and the bearer token are invalidated (the bearer token
+needs the app's API key and secret, see 'xurl auth app', and is only removed
+without them). When X fails to revoke a token, nothing is removed; --local-only skips X and removes the
+tokens from ~/.xurl regardless. --app clears another app's tokens.
+
+Examples:
+  xurl auth clear --oauth2-username alice
+  xurl auth clear --all
+  xurl auth clear --all --local-only
+  xurl auth clear --app my-app --bearer`,
 		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			appName, _ := cmd.Flags().GetString("app")
 			if all {
-				err := a.TokenStore.ClearAll()
+				revokeTokens(localOnly, func() []auth.Revocation { return a.RevokeAll(ctx, appName) })
+				err := a.TokenStore.ClearAllForApp(appName)
 				if err != nil {
 					fmt.Println("Error clearing all tokens:", err)
 					os.Exit(1)
 				}
 				fmt.Println("All authentication cleared!")
 			} else if oauth1 {
-				err := a.TokenStore.ClearOAuth1Tokens()
+				revokeTokens(localOnly, func() []auth.Revocation { return a.RevokeOAuth1Tokens(ctx, appName, "") })
+				err := a.TokenStore.ClearOAuth1TokensForApp(appName)
 				if err != nil {
 					fmt.Println("Error clearing OAuth1 tokens:", err)
 					os.Exit(1)
 				}
 				fmt.Println("OAuth1 tokens cleared!")
+			} else if oauth1Username != "" {
+				revokeTokens(localOnly, func() []auth.Revocation { return a.RevokeOAuth1Tokens(ctx, appName, oauth1Username) })
+				err := a.TokenStore.ClearOAuth1TokensForUser(appName, oauth1Username)
+				if err != nil {
+					fmt.Println("Error clearing OAuth1 tokens:", err)
+					os.Exit(1)
+				}
+				fmt.Println("OAuth1 tokens cleared for", oauth1Username+"!")
 			} else if oauth2Username != "" {
-				err := a.TokenStore.ClearOAuth2Token(oauth2Username)
+				revokeTokens(localOnly, func() []auth.Revocation { return a.RevokeOAuth2Tokens(ctx, appName, oauth2Username) })
+				err := a.TokenStore.ClearOAuth2TokenForApp(appName, oauth2Username)
 				if err != nil {
 					fmt.Println("Error clearing OAuth2 token:", err)
 					os.Exit(1)
 				}
 				fmt.Println("OAuth2 token cleared for", oauth2Username+"!")
 			} else if bearer {
-				err := a.TokenStore.ClearBearerToken()
+				revokeTokens(localOnly, func() []auth.Revocation { return a.RevokeBearerToken(ctx, appName) })
+				err := a.TokenStore.ClearBearerTokenForApp(appName)
 				if err != nil {
 					fmt.Println("Error clearing bearer token:", err)
 					os.Exit(1)
@@ -217,9 +363,11 @@ func createAuthClearCmd(a *auth.Auth) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&all, "all", false, "Clear all authentication")
-	cmd.Flags().BoolVar(&oauth1, "oauth1", false, "Clear OAuth1 tokens")
+	cmd.Flags().BoolVar(&oauth1, "oauth1", false, "Clear the tokens of every OAuth1 account")
+	cmd.Flags().StringVar(&oauth1Username, "oauth1-username", "", "Clear the OAuth1 tokens of one account")
 	cmd.Flags().StringVar(&oauth2Username, "oauth2-username", "", "Clear OAuth2 token for username")
 	cmd.Flags().BoolVar(&bearer, "bearer", false, "Clear bearer token")
+	cmd.Flags().BoolVar(&localOnly, "local-only", false, "Only remove the tokens from ~/.xurl, without revoking them on X")
 
 	return cmd
 }
@@ -248,8 +396,12 @@ func createAppAddCmd(a *auth.Auth) *cobra.Command {
 		Short: "Register a new X API app",
 		Long: `Register a new X API app with a client ID and secret.
 
+Network flags (--proxy, --cacert, --cert, --key, --insecure, --timeout,
+--connect-timeout) given here are saved with the app.
+
 Examples:
-  xurl auth apps add my-app --client-id abc --client-secret xyz`,
+  xurl auth apps add my-app --client-id abc --client-secret xyz
+  xurl auth apps add corp --client-id abc --client-secret xyz --proxy http://proxy:3128 --cacert corp-ca.pem`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
@@ -258,6 +410,12 @@ Examples:
 				fmt.Printf("\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
+			if options := transportFlags(cmd); !options.IsZero() {
+				if err := a.TokenStore.SetAppTransport(name, options, false); err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+			}
 			fmt.Printf("\033[32mApp %q registered!\033[0m\n", name)
 			if len(a.TokenStore.ListApps()) == 1 {
 				fmt.Printf("  (set as default app)\n")
@@ -275,20 +433,29 @@ Examples:
 
 func createAppUpdateCmd(a *auth.Auth) *cobra.Command {
 	var clientID, clientSecret string
+	var resetTransport bool
 
 	cmd := &cobra.Command{
 		Use:   "update NAME",
 		Short: "Update credentials for an existing app",
 		Long: `Update the client ID and/or secret for an existing registered app.
 
+Network flags (--proxy, --cacert, --cert, --key, --insecure, --timeout,
+--connect-timeout) given here are saved with the app and used for all of
+its requests; --reset-transport clears the saved ones first.
+
 Examples:
   xurl auth apps update default --client-id abc --client-secret xyz
-  xurl auth apps update my-app --client-id newid`,
-		Args: cobra.ExactArgs(1),
+  xurl auth apps update my-app --client-id newid
+  xurl auth apps update my-app --proxy socks5://127.0.0.1:1080 --timeout 2m
+  xurl auth apps update my-app --reset-transport`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeOneApp,
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
-			if clientID == "" && clientSecret == "" {
-				fmt.Println("Nothing to update. Provide --client-id and/or --client-secret.")
+			options := transportFlags(cmd)
+			if clientID == "" && clientSecret == "" && options.IsZero() && !resetTransport {
+				fmt.Println("Nothing to update. Provide --client-id, --client-secret and/or network flags.")
 				os.Exit(1)
 			}
 			err := a.TokenStore.UpdateApp(name, clientID, clientSecret)
@@ -296,23 +463,38 @@ Examples:
 				fmt.Printf("\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
+			if !options.IsZero() || resetTransport {
+				if err := a.TokenStore.SetAppTransport(name, options, resetTransport); err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+			}
 			fmt.Printf("\033[32mApp %q updated.\033[0m\n", name)
 		},
 	}
 
 	cmd.Flags().StringVar(&clientID, "client-id", "", "OAuth2 client ID")
 	cmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client secret")
+	cmd.Flags().BoolVar(&resetTransport, "reset-transport", false, "Clear the app's saved network settings")
 
 	return cmd
 }
 
 func createAppRemoveCmd(a *auth.Auth) *cobra.Command {
+	var localOnly bool
+
 	cmd := &cobra.Command{
 		Use:   "remove NAME",
 		Short: "Remove a registered app and all its tokens",
-		Args:  cobra.ExactArgs(1),
+		Long: `Remove a registered app, revoking its tokens on X first as 'xurl auth clear
+--all' does. --local-only skips X.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeOneApp,
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
+			if a.TokenStore.GetApp(name) != nil {
+				revokeTokens(localOnly, func() []auth.Revocation { return a.RevokeAll(cmd.Context(), name) })
+			}
 			err := a.TokenStore.RemoveApp(name)
 			if err != nil {
 				fmt.Printf("\033[31mError: %v\033[0m\n", err)
@@ -321,6 +503,9 @@ func createAppRemoveCmd(a *auth.Auth) *cobra.Command {
 			fmt.Printf("\033[32mApp %q removed.\033[0m\n", name)
 		},
 	}
+
+	cmd.Flags().BoolVar(&localOnly, "local-only", false, "Only remove the app from ~/.xurl, without revoking its tokens on X")
+
 	return cmd
 }
 
@@ -358,6 +543,8 @@ func createAppListCmd() *cobra.Command {
 // ─── auth default ───────────────────────────────────────────────────
 
 func createDefaultCmd(a *auth.Auth) *cobra.Command {
+	var oauth1 bool
+
 	cmd := &cobra.Command{
 		Use:   "default [APP_NAME [USERNAME]]",
 		Short: "Set default app and/or user (interactive or by argument)",
@@ -365,13 +552,16 @@ func createDefaultCmd(a *auth.Auth) *cobra.Command {
 
 Without arguments: launches an interactive picker (Bubble Tea).
 With one argument:  sets the default app.
-With two arguments: sets the default app and default OAuth2 user.
+With two arguments: sets the default app and default OAuth2 user, or with
+                    --oauth1 the default OAuth1 account.
 
 Examples:
   xurl auth default                     # interactive picker
   xurl auth default my-app              # set default app
-  xurl auth default my-app alice        # set default app + user`,
-		Args: cobra.MaximumNArgs(2),
+  xurl auth default my-app alice        # set default app + user
+  xurl auth default my-app bob --oauth1 # set default app + OAuth1 account`,
+		Args:              cobra.MaximumNArgs(2),
+		ValidArgsFunction: completeAppThenUser,
 		Run: func(cmd *cobra.Command, args []string) {
 			ts := a.TokenStore
 
@@ -386,7 +576,11 @@ Examples:
 
 				if len(args) == 2 {
 					userName := args[1]
-					if err := ts.SetDefaultUser(appName, userName); err != nil {
+					setDefault := ts.SetDefaultUser
+					if oauth1 {
+						setDefault = ts.SetDefaultOAuth1User
+					}
+					if err := setDefault(appName, userName); err != nil {
 						fmt.Printf("\033[31mError: %v\033[0m\n", err)
 						os.Exit(1)
 					}
@@ -433,13 +627,59 @@ Examples:
 					fmt.Printf("\033[32mDefault user set to %q\033[0m\n", userChoice)
 				}
 			}
+
+			// And a default OAuth1 account, when there is a choice
+			accounts := ts.GetOAuth1UsernamesForApp(appChoice)
+			if len(accounts) > 1 {
+				accountChoice, err := RunPicker("Select default OAuth1 account", accounts)
+				if err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				if accountChoice != "" {
+					if err := ts.SetDefaultOAuth1User(appChoice, accountChoice); err != nil {
+						fmt.Printf("\033[31mError: %v\033[0m\n", err)
+						os.Exit(1)
+					}
+					fmt.Printf("\033[32mDefault OAuth1 account set to %q\033[0m\n", accountChoice)
+				}
+			}
 		},
 	}
+
+	cmd.Flags().BoolVar(&oauth1, "oauth1", false, "USERNAME is an OAuth1 account")
+
 	return cmd
 }
 
 // ─── helpers ────────────────────────────────────────────────────────
 
+// revokeTokens revokes tokens on X with revoke, unless localOnly, printing
+// the outcome for each. If revoking any fails it stops the command before
+// anything is removed, since they still work for anyone who has a copy.
+// Tokens that can't be revoked at all, such as a pasted bearer token, are
+// skipped and removed like the others.
+func revokeTokens(localOnly bool, revoke func() []auth.Revocation) {
+	if localOnly {
+		return
+	}
+	failed := 0
+	for _, r := range revoke() {
+		if r.Skipped != "" {
+			fmt.Printf("\033[33m- %s skipped (%s)\033[0m\n", r.Token, r.Skipped)
+		} else if r.Err != nil {
+			failed++
+			fmt.Printf("\033[31m✗ %s not revoked: %v\033[0m\n", r.Token, r.Err)
+		} else {
+			fmt.Printf("\033[32m✓ %s revoked\033[0m\n", r.Token)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\033[33m%d token(s) could not be revoked, so nothing was removed. Use --local-only to remove them from ~/.xurl anyway.\033[0m\n", failed)
+		os.Exit(1)
+	}
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/openapi"
+	"github.com/xdevplatform/xurl/store"
+)
+
+// authTypes are the values --auth accepts
+var authTypes = []string{"oauth1", "oauth2", "app"}
+
+// completeAPIPath completes the URL argument of a raw request from the
+// OpenAPI spec: paths, query parameter names and field values
+func completeAPIPath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || !strings.HasPrefix(toComplete, "/") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	spec, err := openapi.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// The URL usually isn't finished when a completion ends in / or =, or
+	// while picking comma separated values
+	return spec.Complete(toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeApps completes registered app names
+func completeApps(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return store.NewTokenStore().ListApps(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeUsernames completes the OAuth2 users of the app given with --app,
+// or of the default app, and its OAuth1 accounts with --auth oauth1
+func completeUsernames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	appName, _ := cmd.Flags().GetString("app")
+	if authType, _ := cmd.Flags().GetString("auth"); authType == "oauth1" {
+		return store.NewTokenStore().GetOAuth1UsernamesForApp(appName), cobra.ShellCompDirectiveNoFileComp
+	}
+	return store.NewTokenStore().GetOAuth2UsernamesForApp(appName), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAuthTypes completes --auth values
+func completeAuthTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return authTypes, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAppThenUser completes an app name, then one of its OAuth2 users,
+// as 'xurl auth default' takes them
+func completeAppThenUser(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeApps(cmd, args, toComplete)
+	case 1:
+		return store.NewTokenStore().GetOAuth2UsernamesForApp(args[0]), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOneApp completes a single app name argument
+func completeOneApp(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeApps(cmd, args, toComplete)
+}
+
+// registerFlagCompletions adds completions for --app, --auth and --username
+// to every command in the tree that has them
+func registerFlagCompletions(cmd *cobra.Command) {
+	completions := map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+		"app":      completeApps,
+		"auth":     completeAuthTypes,
+		"username": completeUsernames,
+	}
+	for name, fn := range completions {
+		if cmd.LocalFlags().Lookup(name) != nil {
+			cmd.RegisterFlagCompletionFunc(name, fn)
+		}
+	}
+	for _, child := range cmd.Commands() {
+		registerFlagCompletions(child)
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/openapi"
+)
+
+// CreateEndpointsCommand creates the endpoints command, which lists the
+// operations in the X API OpenAPI spec
+func CreateEndpointsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "endpoints [FILTER]",
+		Short: "List X API endpoints and the auth types they accept",
+		Long: `List the X API v2 endpoints from the OpenAPI spec, with the auth types
+each accepts. FILTER matches the path, method, operation ID, summary or tag.
+
+xurl checks raw requests against the same spec before sending them. It
+ships with a snapshot of the spec; to use a newer one, download it from
+https://api.x.com/2/openapi.json and install it with --update.`,
+		Example: `  xurl endpoints
+  xurl endpoints bookmarks
+  xurl endpoints -v /2/users/me
+  xurl endpoints --update openapi.json
+  xurl endpoints --reset`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			update, _ := cmd.Flags().GetString("update")
+			reset, _ := cmd.Flags().GetBool("reset")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+
+			if update != "" {
+				data, err := os.ReadFile(update)
+				if err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				spec, err := openapi.Install(data)
+				if err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("\033[32mInstalled OpenAPI spec with %d operations to %s\033[0m\n", len(spec.Operations), spec.Source)
+				return
+			}
+			if reset {
+				if err := openapi.Reset(); err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				fmt.Println("\033[32mUsing the bundled OpenAPI spec\033[0m")
+				return
+			}
+
+			spec, err := openapi.Load()
+			if err != nil {
+				fmt.Printf("\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+
+			operations := spec.Operations
+			if len(args) > 0 {
+				operations = spec.Filter(args[0])
+				if len(operations) == 0 {
+					operations = spec.Find(args[0])
+				}
+			}
+			if len(operations) == 0 {
+				fmt.Println("No endpoints found")
+				return
+			}
+			printOperations(operations, verbose)
+		},
+	}
+
+	cmd.Flags().BoolP("verbose", "v", false, "Also list each endpoint's query parameters and OAuth 2.0 scopes")
+	cmd.Flags().String("update", "", "Install the OpenAPI spec in FILE in place of the bundled snapshot")
+	cmd.Flags().Bool("reset", false, "Remove an installed spec and go back to the bundled snapshot")
+	cmd.MarkFlagsMutuallyExclusive("update", "reset")
+
+	return cmd
+}
+
+// printOperations prints a table of operations, with their parameters when verbose
+func printOperations(operations []*openapi.Operation, verbose bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tAUTH\tSUMMARY")
+	for _, op := range operations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", op.Method, op.Path, strings.Join(op.AuthTypes, ","), op.Summary)
+		if !verbose {
+			continue
+		}
+		if len(op.Scopes) > 0 {
+			fmt.Fprintf(w, "\t  scopes: %s\t\t\n", strings.Join(op.Scopes, " "))
+		}
+		for _, param := range op.Parameters {
+			if param.In != "query" {
+				continue
+			}
+			name := param.Name
+			if param.Required {
+				name += " (required)"
+			}
+			fmt.Fprintf(w, "\t  %s\t\t%s\n", name, strings.Join(param.Enum, ","))
+		}
+	}
+	w.Flush()
+}
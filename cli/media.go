@@ -9,6 +9,7 @@ import (
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
 // CreateMediaCommand creates the media command and its subcommands
@@ -43,12 +44,13 @@ func createMediaUploadCmd(auth *auth.Auth) *cobra.Command {
 			headers, _ := cmd.Flags().GetStringArray("header")
 			trace, _ := cmd.Flags().GetBool("trace")
 			config := config.NewConfig()
-			client := api.NewApiClient(config, auth)
+			client := configureClient(cmd, auth, api.NewApiClient(config, auth))
 
-			err := api.ExecuteMediaUpload(filePath, mediaType, mediaCategory, authType, username, verbose, trace, waitForProcessing, headers, client)
+			err := api.ExecuteMediaUploadContext(cmd.Context(), filePath, mediaType, mediaCategory, authType, username, verbose, trace, waitForProcessing, headers, client)
 			if err != nil {
 				fmt.Printf("\033[31m%v\033[0m\n", err)
-				os.Exit(1)
+				api.PrintErrorHint(err)
+				os.Exit(xurlErrors.ExitCode(err))
 			}
 		},
 	}
@@ -57,7 +59,7 @@ func createMediaUploadCmd(auth *auth.Auth) *cobra.Command {
 	cmd.Flags().StringVar(&mediaCategory, "category", "amplify_video", "Media category (e.g., tweet_image, tweet_video, amplify_video)")
 	cmd.Flags().BoolVar(&waitForProcessing, "wait", true, "Wait for media processing to complete")
 	cmd.Flags().String("auth", "", "Authentication type (oauth1 or oauth2)")
-	cmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication")
+	cmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication, or the OAuth1 account with --auth oauth1")
 	cmd.Flags().BoolP("verbose", "v", false, "Print verbose information")
 	cmd.Flags().BoolP("trace", "t", false, "Add trace header to request")
 	cmd.Flags().StringArrayP("header", "H", []string{}, "Request headers")
@@ -81,18 +83,19 @@ func createMediaStatusCmd(auth *auth.Auth) *cobra.Command {
 			trace, _ := cmd.Flags().GetBool("trace")
 			headers, _ := cmd.Flags().GetStringArray("header")
 			config := config.NewConfig()
-			client := api.NewApiClient(config, auth)
+			client := configureClient(cmd, auth, api.NewApiClient(config, auth))
 
-			err := api.ExecuteMediaStatus(mediaID, authType, username, verbose, wait, trace, headers, client)
+			err := api.ExecuteMediaStatusContext(cmd.Context(), mediaID, authType, username, verbose, wait, trace, headers, client)
 			if err != nil {
 				fmt.Printf("\033[31m%v\033[0m\n", err)
-				os.Exit(1)
+				api.PrintErrorHint(err)
+				os.Exit(xurlErrors.ExitCode(err))
 			}
 		},
 	}
 
 	cmd.Flags().String("auth", "", "Authentication type (oauth1 or oauth2)")
-	cmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication")
+	cmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication, or the OAuth1 account with --auth oauth1")
 	cmd.Flags().BoolP("verbose", "v", false, "Print verbose information")
 	cmd.Flags().BoolP("wait", "w", false, "Wait for media processing to complete")
 	cmd.Flags().BoolP("trace", "t", false, "Add trace header to request")
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/mock"
+)
+
+// CreateMockCommand creates the mock command and its subcommands
+func CreateMockCommand() *cobra.Command {
+	mockCmd := &cobra.Command{
+		Use:   "mock",
+		Short: "Run a local mock of the X API",
+	}
+
+	var port int
+	var heartbeat time.Duration
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an in-memory mock of the X API v2 endpoints the shortcuts use",
+		Long: `Starts a local HTTP server that emulates the part of the X API v2 used by
+xurl's shortcut commands: posts, search, users, likes, reposts, bookmarks,
+follows, blocks, mutes, DMs, media upload and the filtered stream with its
+rules. State is kept in memory and any credentials are accepted, so it works
+in CI and demos without an X developer account.
+
+Point xurl at it with API_BASE_URL:
+
+  xurl mock serve --port 8080 &
+  export API_BASE_URL=http://localhost:8080
+  xurl whoami
+  xurl post "Hello from the mock!"
+  xurl search hello`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			server := mock.NewServer()
+			server.Heartbeat = heartbeat
+
+			listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			httpServer := &http.Server{Handler: server}
+
+			go func() {
+				<-cmd.Context().Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				httpServer.Shutdown(ctx)
+			}()
+
+			addr := fmt.Sprintf("http://%s", listener.Addr())
+			fmt.Printf("\033[32mMock X API listening on %s\033[0m\n", addr)
+			fmt.Printf("Signed in as @%s. Use it with:\n  export API_BASE_URL=%s\n", mock.MeUsername, addr)
+
+			if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+	serveCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on (0 picks a free port)")
+	serveCmd.Flags().DurationVar(&heartbeat, "heartbeat", 20*time.Second, "Interval between keep-alive newlines on streams")
+
+	mockCmd.AddCommand(serveCmd)
+	return mockCmd
+}
@@ -3,14 +3,27 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/format"
+	"github.com/xdevplatform/xurl/jq"
+	"github.com/xdevplatform/xurl/openapi"
+	"github.com/xdevplatform/xurl/transport"
 )
 
+// responseQuery is the compiled --jq expression, or nil when none was given
+var responseQuery *jq.Query
+
+// responseWriter renders shortcut results in the --format given, or is nil for JSON
+var responseWriter *format.Writer
+
 // CreateRootCommand creates the root command for the xurl CLI
 func CreateRootCommand(cfg *config.Config, a *auth.Auth) *cobra.Command {
 	var rootCmd = &cobra.Command{
@@ -34,13 +47,32 @@ Shortcut commands (agent‑friendly):
 Raw API access (curl‑style):
   basic requests        xurl /2/users/me
                         xurl -X POST /2/tweets -d '{"text":"Hello world!"}'
+                        xurl /2/tweets -d @post.json
+                        xurl /2/tweets --json text="Hello world!" reply_settings=following
+                        xurl /2/tweets/search/recent --param "query=from:XDevelopers -is:retweet"
+                        xurl /2/media/upload -F media=@cat.png -F media_category=tweet_image
+  curl commands         xurl --dry-run /2/tweets --json text=hi
+                        xurl --curl --show-auth /2/users/me
                         xurl -H "Content-Type: application/json" /2/tweets
+  pagination            xurl --paginate "/2/users/123/followers?max_results=1000"
+                        xurl --paginate --max-pages 5 --ndjson "/2/tweets/search/recent?query=golang"
+  filtering             xurl /2/users/me --jq .data.username
+                        xurl search "golang" --jq '.data[] | select(.author_id == "12") | .id'
+  output                xurl -i /2/users/me
+                        xurl -o me.json -w '%{http_code} %{x_rate_limit_remaining}\n' /2/users/me
+  endpoints             xurl endpoints bookmarks
+                        xurl --no-validate /2/labs/new-endpoint
   authentication        xurl --auth oauth2 /2/users/me
                         xurl --auth oauth1 /2/users/me
                         xurl --auth app /2/users/me
   media and streaming   xurl media upload path/to/video.mp4
                         xurl /2/tweets/search/stream --auth app
+                        xurl /2/tweets/search/stream --reconnect --backfill 2
+                        xurl /2/tweets/search/stream -o posts.ndjson --rotate-size 100MB --gzip
+                        xurl /2/tweets/search/stream --max-events 100 --duration 10m
                         xurl -s /2/users/me
+                        xurl stream rules add --value "golang -is:retweet" --tag golang
+                        xurl stream rules sync rules.yaml --dry-run
 
 Multi-app management:
   xurl auth apps add my-app --client-id ... --client-secret ...
@@ -49,6 +81,10 @@ Multi-app management:
   xurl auth default my-app                         # set by name
   xurl --app my-app /2/users/me                    # per-request override
 
+Network settings (also stored per app with 'xurl auth apps update NAME ...'):
+  xurl --proxy socks5://127.0.0.1:1080 /2/users/me
+  xurl --cacert corp-ca.pem --timeout 2m "/2/tweets/search/all?query=golang"
+
 Run 'xurl --help' to see all available commands.`,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			// Apply --app override if provided
@@ -56,24 +92,88 @@ Run 'xurl --help' to see all available commands.`,
 			if appOverride != "" {
 				a.WithAppName(appOverride)
 			}
+
+			// Build the shared transport from the app's settings and flags.
+			// Local commands skip it, so a bad setting can still be fixed.
+			if sendsRequests(cmd) {
+				if err := configureTransport(cmd, a, appOverride); err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+			}
+
+			record, _ := cmd.Flags().GetString("record")
+			replay, _ := cmd.Flags().GetString("replay")
+			if record != "" && replay != "" {
+				fmt.Printf("\033[31mError: --record and --replay can't be used together\033[0m\n")
+				os.Exit(1)
+			}
+
+			// Compile --jq up front so a typo fails before anything is sent
+			if expr, _ := cmd.Flags().GetString("jq"); expr != "" {
+				query, err := jq.Compile(expr)
+				if err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				responseQuery = query
+			}
+
+			// Shortcut commands take --format
+			if flag := cmd.Flags().Lookup("format"); flag != nil && flag.Value.String() != "" {
+				f, err := format.Parse(flag.Value.String())
+				if err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					os.Exit(1)
+				}
+				if f != format.JSON && responseQuery != nil {
+					fmt.Printf("\033[31mError: --format and --jq can't be used together\033[0m\n")
+					os.Exit(1)
+				}
+				if f != format.JSON {
+					responseWriter = format.NewWriter(os.Stdout, f)
+				}
+			}
 		},
 		Args: func(cmd *cobra.Command, args []string) error {
 			return nil
 		},
+		ValidArgsFunction: completeAPIPath,
 		Run: func(cmd *cobra.Command, args []string) {
 			method, _ := cmd.Flags().GetString("method")
-			if method == "" {
-				method = "GET"
-			}
-
 			headers, _ := cmd.Flags().GetStringArray("header")
 			data, _ := cmd.Flags().GetString("data")
+			dataURLEncode, _ := cmd.Flags().GetStringArray("data-urlencode")
+			jsonFields, _ := cmd.Flags().GetStringArray("json")
+			params, _ := cmd.Flags().GetStringArray("param")
+			forms, _ := cmd.Flags().GetStringArray("form")
 			authType, _ := cmd.Flags().GetString("auth")
 			username, _ := cmd.Flags().GetString("username")
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			trace, _ := cmd.Flags().GetBool("trace")
 			forceStream, _ := cmd.Flags().GetBool("stream")
 			mediaFile, _ := cmd.Flags().GetString("file")
+			paginate, _ := cmd.Flags().GetBool("paginate")
+			maxPages, _ := cmd.Flags().GetInt("max-pages")
+			limit, _ := cmd.Flags().GetInt("limit")
+			ndjson, _ := cmd.Flags().GetBool("ndjson")
+			include, _ := cmd.Flags().GetBool("include")
+			outputFile, _ := cmd.Flags().GetString("output")
+			raw, _ := cmd.Flags().GetBool("raw")
+			writeOut, _ := cmd.Flags().GetString("write-out")
+			noValidate, _ := cmd.Flags().GetBool("no-validate")
+			reconnect, _ := cmd.Flags().GetBool("reconnect")
+			maxReconnects, _ := cmd.Flags().GetInt("max-reconnects")
+			stallTimeout, _ := cmd.Flags().GetDuration("stall-timeout")
+			if reconnect && !cmd.Flags().Changed("stall-timeout") {
+				stallTimeout = api.DefaultStallTimeout
+			}
+			backfill, _ := cmd.Flags().GetInt("backfill")
+			maxEvents, _ := cmd.Flags().GetInt("max-events")
+			duration, _ := cmd.Flags().GetDuration("duration")
+			rotateSizeFlag, _ := cmd.Flags().GetString("rotate-size")
+			rotateEvery, _ := cmd.Flags().GetDuration("rotate-every")
+			gzipRotated, _ := cmd.Flags().GetBool("gzip")
 
 			if len(args) == 0 {
 				fmt.Println("No URL provided")
@@ -84,46 +184,321 @@ Run 'xurl --help' to see all available commands.`,
 
 			url := args[0]
 
-			client := api.NewApiClient(cfg, a)
+			// Items after the URL belong to --json, httpie style:
+			//   xurl /2/tweets --json text=hello reply_settings=following
+			if cmd.Flags().Changed("json") {
+				jsonFields = append(jsonFields, args[1:]...)
+			}
 
-			requestOptions := api.RequestOptions{
-				Method:   method,
-				Endpoint: url,
-				Headers:  headers,
-				Data:     data,
-				AuthType: authType,
-				Username: username,
-				Verbose:  verbose,
-				Trace:    trace,
-			}
-			err := api.HandleRequest(requestOptions, forceStream, mediaFile, client)
+			data, err := buildRequestData(data, dataURLEncode)
 			if err != nil {
 				fmt.Printf("\033[31mError: %v\033[0m\n", err)
 				os.Exit(1)
 			}
+
+			formParts, legacyFile, err := parseForms(forms)
+			if err != nil {
+				fmt.Printf("\033[31mError: %v\033[0m\n", err)
+				os.Exit(1)
+			}
+			if mediaFile == "" {
+				mediaFile = legacyFile
+			}
+
+			// Like curl, sending a body implies POST
+			if method == "" {
+				method = "GET"
+				if data != "" || len(jsonFields) > 0 || len(formParts) > 0 {
+					method = "POST"
+				}
+			}
+
+			if backfill < 0 || backfill > 5 {
+				fmt.Printf("\033[31mError: --backfill must be between 0 and 5 minutes\033[0m\n")
+				os.Exit(xurlErrors.ExitUsage)
+			}
+			rotateSize, err := parseSize(rotateSizeFlag)
+			if err != nil {
+				fmt.Printf("\033[31mError: --rotate-size: %v\033[0m\n", err)
+				os.Exit(xurlErrors.ExitUsage)
+			}
+			if outputFile == "" && (rotateSize > 0 || rotateEvery > 0 || gzipRotated) {
+				fmt.Printf("\033[31mError: --rotate-size, --rotate-every and --gzip need --output\033[0m\n")
+				os.Exit(xurlErrors.ExitUsage)
+			}
+
+			if !noValidate {
+				warnings, err := validateRequest(method, url, params)
+				for _, warning := range warnings {
+					fmt.Fprintf(os.Stderr, "\033[33mWarning: %s\033[0m\n", warning)
+				}
+				if err != nil {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+					fmt.Println("Use --no-validate to send it anyway.")
+					os.Exit(xurlErrors.ExitCode(err))
+				}
+			}
+
+			client := configureClient(cmd, a, api.NewApiClient(cfg, a))
+
+			requestOptions := api.RequestOptions{
+				Method:     method,
+				Endpoint:   url,
+				Headers:    headers,
+				Data:       data,
+				AuthType:   authType,
+				Username:   username,
+				Verbose:    verbose,
+				Trace:      trace,
+				JSONFields: jsonFields,
+				Params:     params,
+				Form:       formParts,
+				Query:      responseQuery,
+				Curl:       curlOpts(cmd),
+				Stream: &api.StreamOptions{
+					Reconnect:       reconnect,
+					MaxReconnects:   maxReconnects,
+					StallTimeout:    stallTimeout,
+					BackfillMinutes: backfill,
+					MaxEvents:       maxEvents,
+					Duration:        duration,
+					RotateSize:      rotateSize,
+					RotateEvery:     rotateEvery,
+					Gzip:            gzipRotated,
+				},
+			}
+			if paginate {
+				requestOptions.Pagination = &api.PaginationOptions{
+					MaxPages: maxPages,
+					Limit:    limit,
+				}
+				if ndjson {
+					requestOptions.Pagination.OnPage = printPage
+				}
+			}
+			if include || outputFile != "" || raw || writeOut != "" {
+				requestOptions.Output = &api.OutputOptions{
+					Include:  include,
+					File:     outputFile,
+					Raw:      raw,
+					WriteOut: writeOut,
+				}
+			}
+			err = api.HandleRequest(requestOptions, forceStream, mediaFile, client)
+			if err != nil {
+				if !xurlErrors.IsReported(err) {
+					fmt.Printf("\033[31mError: %v\033[0m\n", err)
+				}
+				os.Exit(xurlErrors.ExitCode(err))
+			}
 		},
 	}
 
 	// Global persistent flag: --app
 	rootCmd.PersistentFlags().String("app", "", "Use a specific registered app (overrides default)")
+	rootCmd.PersistentFlags().Bool("wait-on-limit", false, "When rate limited, wait for the window to reset and retry instead of failing")
+	rootCmd.PersistentFlags().Int("retries", 0, "Retry network errors, 5xx and 429 responses this many times with jittered exponential backoff")
+	rootCmd.PersistentFlags().Duration("retry-max-time", 0, "Stop retrying once this much time has passed, e.g. 2m (0 = no limit)")
+	rootCmd.PersistentFlags().Bool("retry-post", false, "Also retry POST and PATCH requests, which may not be idempotent")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Overall request timeout, e.g. 2m (default 30s; streams are not limited)")
+	rootCmd.PersistentFlags().Duration("connect-timeout", 0, "Timeout for connecting and the TLS handshake (default 30s)")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL (http://, https://, socks5:// or socks5h://); defaults to HTTPS_PROXY")
+	rootCmd.PersistentFlags().String("cacert", "", "PEM file of extra CA certificates to trust")
+	rootCmd.PersistentFlags().String("cert", "", "PEM client certificate for mutual TLS (requires --key)")
+	rootCmd.PersistentFlags().String("key", "", "PEM private key for --cert")
+	rootCmd.PersistentFlags().BoolP("insecure", "k", false, "Skip TLS certificate verification")
+	rootCmd.PersistentFlags().String("record", "", "Record requests and responses, without credentials, as cassettes in this directory")
+	rootCmd.PersistentFlags().String("replay", "", "Answer requests from the cassettes in this directory instead of the network")
+	rootCmd.PersistentFlags().String("jq", "", "Filter the response (or each streamed line) with a jq expression, e.g. '.data[].id'")
 
-	rootCmd.Flags().StringP("method", "X", "", "HTTP method (GET by default)")
+	rootCmd.Flags().StringP("method", "X", "", "HTTP method (GET by default, POST when a body is given)")
 	rootCmd.Flags().StringArrayP("header", "H", []string{}, "Request headers")
-	rootCmd.Flags().StringP("data", "d", "", "Request body data")
+	rootCmd.Flags().StringP("data", "d", "", "Request body data, @FILE to read it from a file or @- from stdin")
+	rootCmd.Flags().StringArray("data-urlencode", []string{}, "URL-encode and add form data: content, name=content, @FILE or name@FILE")
+	rootCmd.Flags().StringArray("json", []string{}, "Build a JSON body from key=value (string) or key:=value (raw JSON) items")
+	rootCmd.Flags().StringArray("param", []string{}, "Add a key=value query parameter, escaped as needed")
 	rootCmd.Flags().String("auth", "", "Authentication type (oauth1 or oauth2)")
-	rootCmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication")
+	rootCmd.Flags().StringP("username", "u", "", "Username for OAuth2 authentication, or the OAuth1 account with --auth oauth1")
 	rootCmd.Flags().BoolP("verbose", "v", false, "Print verbose information")
 	rootCmd.Flags().BoolP("trace", "t", false, "Add trace header to request")
 	rootCmd.Flags().BoolP("stream", "s", false, "Force streaming mode for non-streaming endpoints")
-	rootCmd.Flags().StringP("file", "F", "", "File to upload (for multipart requests)")
+	rootCmd.Flags().Bool("reconnect", false, "Reconnect dropped or stalled streams, backing off as X recommends")
+	rootCmd.Flags().Int("max-reconnects", 0, "With --reconnect, give up after this many failed attempts in a row (0 = no limit)")
+	rootCmd.Flags().Duration("stall-timeout", 0, "Drop a stream that sends no data or heartbeat for this long (default 30s with --reconnect, otherwise never)")
+	rootCmd.Flags().Int("backfill", 0, "With --reconnect, ask for up to 5 minutes of posts missed while disconnected")
+	rootCmd.Flags().Int("max-events", 0, "End a stream after this many lines, heartbeats aside (0 = no limit)")
+	rootCmd.Flags().Duration("duration", 0, "End a stream after this long, e.g. 10m (0 = no limit)")
+	rootCmd.Flags().String("rotate-size", "", "With --output, start a new stream file once it reaches this size, e.g. 100MB")
+	rootCmd.Flags().Duration("rotate-every", 0, "With --output, start a new stream file this often, e.g. 1h")
+	rootCmd.Flags().Bool("gzip", false, "With --output, gzip rotated stream files")
+	rootCmd.Flags().StringArrayP("form", "F", []string{}, "Multipart form part: name=value or name=@FILE[;type=MIME][;filename=NAME]")
+	rootCmd.Flags().String("file", "", "File to upload for a media APPEND request")
+	addCurlFlags(rootCmd)
+	rootCmd.Flags().Bool("paginate", false, "Follow meta.next_token and merge all pages into one response")
+	rootCmd.Flags().Int("max-pages", 0, "With --paginate, stop after this many pages (0 = no limit)")
+	rootCmd.Flags().Int("limit", 0, "With --paginate, stop after this many results (0 = no limit)")
+	rootCmd.Flags().Bool("ndjson", false, "With --paginate, print each page as one JSON line instead of merging")
+	rootCmd.Flags().BoolP("include", "i", false, "Print the response status line and headers before the body")
+	rootCmd.Flags().StringP("output", "o", "", "Write the response body to FILE instead of stdout (streams are appended)")
+	rootCmd.Flags().Bool("raw", false, "Print the response body exactly as received, without formatting")
+	rootCmd.Flags().StringP("write-out", "w", "", "Print a template after the response, e.g. '%{http_code} %{time_total}\\n'")
+	rootCmd.Flags().Bool("no-validate", false, "Send the request without checking it against the OpenAPI spec")
 
 	rootCmd.AddCommand(CreateAuthCommand(a))
 	rootCmd.AddCommand(CreateMediaCommand(a))
 	rootCmd.AddCommand(CreateVersionCommand())
 	rootCmd.AddCommand(CreateWebhookCommand(a))
+	rootCmd.AddCommand(CreateMockCommand())
+	rootCmd.AddCommand(CreateEndpointsCommand())
+	rootCmd.AddCommand(CreateStreamCommand(a))
 
 	// Register streamlined shortcut commands (post, reply, read, search, etc.)
 	CreateShortcutCommands(rootCmd, a)
 
+	registerFlagCompletions(rootCmd)
+
 	return rootCmd
 }
+
+// transportFlags returns the transport options given on the command line
+func transportFlags(cmd *cobra.Command) transport.Options {
+	var options transport.Options
+	options.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	options.ConnectTimeout, _ = cmd.Flags().GetDuration("connect-timeout")
+	options.Proxy, _ = cmd.Flags().GetString("proxy")
+	options.CACert, _ = cmd.Flags().GetString("cacert")
+	options.Cert, _ = cmd.Flags().GetString("cert")
+	options.Key, _ = cmd.Flags().GetString("key")
+	options.Insecure, _ = cmd.Flags().GetBool("insecure")
+	return options
+}
+
+// localCommands never send a request, by their path below the root command.
+// Subcommands of a listed command are local too.
+var localCommands = map[string]bool{
+	"help":             true,
+	"version":          true,
+	"completion":       true,
+	"endpoints":        true,
+	"mock":             true,
+	"auth status":      true,
+	"auth default":     true,
+	"auth apps add":    true,
+	"auth apps update": true,
+	"auth apps list":   true,
+	"__complete":       true,
+	"__completeNoDesc": true,
+}
+
+// sendsRequests reports whether cmd may talk to X and so needs the transport
+func sendsRequests(cmd *cobra.Command) bool {
+	path := strings.Fields(cmd.CommandPath())[1:]
+	for i := range path {
+		if localCommands[strings.Join(path[:i+1], " ")] {
+			return false
+		}
+	}
+	return true
+}
+
+// configureTransport builds the HTTP client shared by API requests and the
+// OAuth flows from the app's stored settings, overridden by flags
+func configureTransport(cmd *cobra.Command, a *auth.Auth, appName string) error {
+	var options transport.Options
+	if app := a.TokenStore.ResolveApp(appName); app != nil && app.Transport != nil {
+		options = *app.Transport
+	}
+	options = options.Merge(transportFlags(cmd))
+
+	client, err := transport.NewClient(options)
+	if err != nil {
+		return err
+	}
+	a.WithHTTPClient(client)
+	return nil
+}
+
+// buildRequestData resolves -d @FILE / @- and appends --data-urlencode
+// values, joining them with & as curl does
+func buildRequestData(data string, dataURLEncode []string) (string, error) {
+	data, err := api.ReadData(data, os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	if len(dataURLEncode) == 0 {
+		return data, nil
+	}
+
+	encoded, err := api.URLEncodeData(dataURLEncode, os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	if data == "" {
+		return encoded, nil
+	}
+	return data + "&" + encoded, nil
+}
+
+// parseForms parses --form values. For compatibility a value without "=" is
+// the file of a media APPEND request, as -F used to be.
+func parseForms(forms []string) ([]api.FormPart, string, error) {
+	var parts []api.FormPart
+	mediaFile := ""
+	for _, form := range forms {
+		if !strings.Contains(form, "=") {
+			mediaFile = form
+			continue
+		}
+		part, err := api.ParseFormPart(form)
+		if err != nil {
+			return nil, "", err
+		}
+		parts = append(parts, part)
+	}
+	return parts, mediaFile, nil
+}
+
+// validateRequest checks a raw request against the OpenAPI spec, including
+// the --param values, and returns what the spec doesn't know as warnings
+func validateRequest(method, endpoint string, params []string) ([]string, error) {
+	spec, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 {
+		if endpoint, err = api.AddQueryParams(endpoint, params); err != nil {
+			return nil, err
+		}
+	}
+	return spec.Validate(method, endpoint)
+}
+
+// sizeUnits are the suffixes parseSize accepts, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// parseSize parses a size such as 500K, 100MB or 1G; "" is 0
+func parseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500K, 100MB or 1G)", size)
+	}
+	return n * multiplier, nil
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/xdevplatform/xurl/api"
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 	"github.com/xdevplatform/xurl/utils"
 )
 
@@ -29,13 +31,59 @@ func baseOpts(cmd *cobra.Command) api.RequestOptions {
 		Username: username,
 		Verbose:  verbose,
 		Trace:    trace,
+		Curl:     curlOpts(cmd),
 	}
 }
 
+// curlOpts returns the --curl/--dry-run settings, or nil when neither is set.
+func curlOpts(cmd *cobra.Command) *api.CurlOptions {
+	printCurl, _ := cmd.Flags().GetBool("curl")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if !printCurl && !dryRun {
+		return nil
+	}
+	showAuth, _ := cmd.Flags().GetBool("show-auth")
+	return &api.CurlOptions{DryRun: dryRun, ShowAuth: showAuth}
+}
+
 // newClient creates an ApiClient from the auth object.
-func newClient(a *auth.Auth) *api.ApiClient {
+func newClient(cmd *cobra.Command, a *auth.Auth) *api.ApiClient {
 	cfg := config.NewConfig()
-	return api.NewApiClient(cfg, a)
+	return configureClient(cmd, a, api.NewApiClient(cfg, a))
+}
+
+// configureClient applies the global client flags (set on the root command)
+// and the shared transport to a new ApiClient.
+func configureClient(cmd *cobra.Command, a *auth.Auth, client *api.ApiClient) *api.ApiClient {
+	waitOnLimit, _ := cmd.Flags().GetBool("wait-on-limit")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryMaxTime, _ := cmd.Flags().GetDuration("retry-max-time")
+	retryPost, _ := cmd.Flags().GetBool("retry-post")
+	client = client.WithContext(cmd.Context()).WithHTTPClient(a.HTTPClient()).WithWaitOnLimit(waitOnLimit).WithRetryPolicy(api.RetryPolicy{
+		MaxRetries: retries,
+		MaxTime:    retryMaxTime,
+		RetryPOST:  retryPost,
+	})
+
+	if dir, _ := cmd.Flags().GetString("record"); dir != "" {
+		client = client.WithRecorder(dir)
+	}
+	if dir, _ := cmd.Flags().GetString("replay"); dir != "" {
+		client = client.WithReplay(dir)
+	}
+	return client
+}
+
+// exitOnError prints an error that stops a shortcut and exits with the code
+// for its class
+func exitOnError(err error) {
+	// API errors are summarized by PrintErrorHint rather than dumped as JSON
+	var e *xurlErrors.Error
+	if !errors.As(err, &e) || e.Problem == nil {
+		fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
+	}
+	api.PrintErrorHint(err)
+	os.Exit(xurlErrors.ExitCode(err))
 }
 
 // printResult pretty‑prints a JSON response or exits on error.
@@ -48,13 +96,50 @@ func printResult(resp json.RawMessage, err error) {
 		} else {
 			fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
 		}
-		os.Exit(1)
+		api.PrintErrorHint(err)
+		os.Exit(xurlErrors.ExitCode(err))
+	}
+	// Nothing to print when pages were already streamed with --ndjson
+	if resp == nil {
+		return
+	}
+	api.PrintPartialErrors(resp)
+	if responseQuery != nil || responseWriter != nil {
+		if err := printPage(resp); err != nil {
+			exitOnError(err)
+		}
+		return
 	}
 	utils.FormatAndPrintResponse(resp)
 }
 
+// printPage prints one page of a paginated response as a single NDJSON line,
+// or as the --jq results or --format rendering of the page.
+func printPage(page json.RawMessage) error {
+	if responseQuery != nil {
+		return responseQuery.Apply(page, os.Stdout)
+	}
+	if responseWriter != nil {
+		return responseWriter.Write(page)
+	}
+	line, err := api.CompactJSON(page)
+	if err != nil {
+		return err
+	}
+	fmt.Println(line)
+	return nil
+}
+
 // resolveMyUserID calls /2/users/me and returns the authenticated user's ID.
 func resolveMyUserID(client api.Client, opts api.RequestOptions) (string, error) {
+	if opts.Curl != nil && opts.Curl.DryRun {
+		// Print the lookup too; a placeholder stands in for the ID it would return
+		if _, err := api.GetMe(client, opts); err != nil {
+			return "", err
+		}
+		return "{my_user_id}", nil
+	}
+	opts.Curl = nil
 	resp, err := api.GetMe(client, opts)
 	if err != nil {
 		return "", fmt.Errorf("could not resolve your user ID (are you authenticated?): %w", err)
@@ -75,6 +160,13 @@ func resolveMyUserID(client api.Client, opts api.RequestOptions) (string, error)
 
 // resolveUserID looks up a username and returns its user ID.
 func resolveUserID(client api.Client, username string, opts api.RequestOptions) (string, error) {
+	if opts.Curl != nil && opts.Curl.DryRun {
+		if _, err := api.LookupUser(client, username, opts); err != nil {
+			return "", err
+		}
+		return "{" + api.ResolveUsername(username) + "_user_id}", nil
+	}
+	opts.Curl = nil
 	resp, err := api.LookupUser(client, username, opts)
 	if err != nil {
 		return "", fmt.Errorf("could not look up user @%s: %w", username, err)
@@ -93,12 +185,56 @@ func resolveUserID(client api.Client, username string, opts api.RequestOptions)
 	return user.Data.ID, nil
 }
 
-// addCommonFlags adds --auth, --username, --verbose, --trace to a command.
+// addCommonFlags adds --auth, --username, --verbose, --trace, --format and
+// the curl flags to a command.
 func addCommonFlags(cmd *cobra.Command) {
 	cmd.Flags().String("auth", "", "Authentication type (oauth1, oauth2, app)")
-	cmd.Flags().StringP("username", "u", "", "OAuth2 username to act as")
+	cmd.Flags().StringP("username", "u", "", "Username to act as (OAuth2, or OAuth1 with --auth oauth1)")
 	cmd.Flags().BoolP("verbose", "v", false, "Print verbose request/response info")
 	cmd.Flags().BoolP("trace", "t", false, "Add X-B3-Flags trace header")
+	cmd.Flags().String("format", "", "Output format: json, table, csv, ndjson or yaml")
+	addCurlFlags(cmd)
+}
+
+// addCurlFlags adds --curl, --dry-run and --show-auth to a command.
+func addCurlFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("curl", false, "Print the equivalent curl command to stderr before sending")
+	cmd.Flags().Bool("dry-run", false, "Print the equivalent curl command instead of sending the request")
+	cmd.Flags().Bool("show-auth", false, "Show the real Authorization header in --curl/--dry-run output instead of redacting it")
+}
+
+// addPaginationFlags adds --paginate, --max-pages and --ndjson to a list command.
+func addPaginationFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("paginate", false, "Follow next_token across pages (-n becomes the total number of results)")
+	cmd.Flags().Int("max-pages", 0, "Stop paginating after this many pages (0 = no limit)")
+	cmd.Flags().Bool("ndjson", false, "When paginating, print each page as one JSON line instead of merging")
+}
+
+// applyPagination sets opts.Pagination from the pagination flags and returns
+// the max_results to request per page, between the endpoint's pageMin and
+// pageMax. Pagination is turned on automatically when -n asks for more than
+// pageMax, or for fewer than pageMin so the page can be trimmed to -n.
+func applyPagination(cmd *cobra.Command, opts *api.RequestOptions, maxResults, pageMin, pageMax int) int {
+	paginate, _ := cmd.Flags().GetBool("paginate")
+	if !paginate && maxResults >= pageMin && maxResults <= pageMax {
+		return maxResults
+	}
+
+	maxPages, _ := cmd.Flags().GetInt("max-pages")
+	ndjson, _ := cmd.Flags().GetBool("ndjson")
+
+	pagination := &api.PaginationOptions{MaxPages: maxPages}
+	if ndjson {
+		pagination.OnPage = printPage
+	}
+	opts.Pagination = pagination
+
+	// Without an explicit -n, fetch everything in pages as large as allowed
+	if !cmd.Flags().Changed("max-results") {
+		return pageMax
+	}
+	pagination.Limit = maxResults
+	return max(pageMin, min(maxResults, pageMax))
 }
 
 // -----------------------------------------------------------------
@@ -156,7 +292,7 @@ Examples:
   xurl post "Multiple images" --media-id 111 --media-id 222`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.CreatePost(client, args[0], mediaIDs, opts))
 		},
@@ -178,7 +314,7 @@ Examples:
   xurl reply https://x.com/user/status/1234567890 "Nice post!"`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.ReplyToPost(client, args[0], args[1], mediaIDs, opts))
 		},
@@ -199,7 +335,7 @@ Examples:
   xurl quote https://x.com/user/status/1234567890 "Interesting take"`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.QuotePost(client, args[0], args[1], opts))
 		},
@@ -219,7 +355,7 @@ Examples:
   xurl delete https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.DeletePost(client, args[0], opts))
 		},
@@ -243,7 +379,7 @@ Examples:
   xurl read https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.ReadPost(client, args[0], opts))
 		},
@@ -262,15 +398,18 @@ func searchCmd(a *auth.Auth) *cobra.Command {
 Examples:
   xurl search "golang"
   xurl search "from:elonmusk" -n 20
-  xurl search "#buildinpublic" -n 15`,
+  xurl search "#buildinpublic" -n 15
+  xurl search "golang" --paginate --max-pages 3`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
-			printResult(api.SearchPosts(client, args[0], maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 10, 100)
+			printResult(api.SearchPosts(client, args[0], perPage, opts))
 		},
 	}
-	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (min 10, max 100)")
+	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (pages of 10 to 100 are fetched and trimmed to this)")
+	addPaginationFlags(cmd)
 	addCommonFlags(cmd)
 	return cmd
 }
@@ -289,7 +428,7 @@ Examples:
   xurl whoami`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.GetMe(client, opts))
 		},
@@ -309,7 +448,7 @@ Examples:
   xurl user @XDevelopers`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			printResult(api.LookupUser(client, args[0], opts))
 		},
@@ -334,17 +473,18 @@ Examples:
   xurl timeline -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
-			printResult(api.GetTimeline(client, userID, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 100)
+			printResult(api.GetTimeline(client, userID, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
+	addPaginationFlags(cmd)
 	addCommonFlags(cmd)
 	return cmd
 }
@@ -361,17 +501,18 @@ Examples:
   xurl mentions -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
-			printResult(api.GetMentions(client, userID, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 100)
+			printResult(api.GetMentions(client, userID, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (5–100)")
+	addPaginationFlags(cmd)
 	addCommonFlags(cmd)
 	return cmd
 }
@@ -391,12 +532,11 @@ Examples:
   xurl like https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.LikePost(client, userID, args[0], opts))
 		},
@@ -411,12 +551,11 @@ func unlikeCmd(a *auth.Auth) *cobra.Command {
 		Short: "Unlike a post",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.UnlikePost(client, userID, args[0], opts))
 		},
@@ -436,12 +575,11 @@ Examples:
   xurl repost https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.Repost(client, userID, args[0], opts))
 		},
@@ -456,12 +594,11 @@ func unrepostCmd(a *auth.Auth) *cobra.Command {
 		Short: "Undo a repost",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.Unrepost(client, userID, args[0], opts))
 		},
@@ -481,12 +618,11 @@ Examples:
   xurl bookmark https://x.com/user/status/1234567890`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.Bookmark(client, userID, args[0], opts))
 		},
@@ -501,12 +637,11 @@ func unbookmarkCmd(a *auth.Auth) *cobra.Command {
 		Short: "Remove a bookmark",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.Unbookmark(client, userID, args[0], opts))
 		},
@@ -527,17 +662,18 @@ Examples:
   xurl bookmarks -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
-			printResult(api.GetBookmarks(client, userID, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 100)
+			printResult(api.GetBookmarks(client, userID, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
+	addPaginationFlags(cmd)
 	addCommonFlags(cmd)
 	return cmd
 }
@@ -554,17 +690,18 @@ Examples:
   xurl likes -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			userID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
-			printResult(api.GetLikedPosts(client, userID, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 100)
+			printResult(api.GetLikedPosts(client, userID, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
+	addPaginationFlags(cmd)
 	addCommonFlags(cmd)
 	return cmd
 }
@@ -584,17 +721,15 @@ Examples:
   xurl follow @XDevelopers`,
 		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			myID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.FollowUser(client, myID, targetID, opts))
 		},
@@ -609,17 +744,15 @@ func unfollowCmd(a *auth.Auth) *cobra.Command {
 		Short: "Unfollow a user",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			myID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.UnfollowUser(client, myID, targetID, opts))
 		},
@@ -641,7 +774,7 @@ Examples:
   xurl following --of elonmusk -n 50`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			var userID string
 			var err error
@@ -651,13 +784,14 @@ Examples:
 				userID, err = resolveMyUserID(client, opts)
 			}
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
-			printResult(api.GetFollowing(client, userID, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 1000)
+			printResult(api.GetFollowing(client, userID, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–1000)")
+	addPaginationFlags(cmd)
 	cmd.Flags().StringVar(&targetUser, "of", "", "Username to list following for (default: you)")
 	addCommonFlags(cmd)
 	return cmd
@@ -673,10 +807,11 @@ func followersCmd(a *auth.Auth) *cobra.Command {
 
 Examples:
   xurl followers
-  xurl followers --of elonmusk -n 50`,
+  xurl followers --of elonmusk -n 50
+  xurl followers -n 5000 --ndjson`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			var userID string
 			var err error
@@ -686,13 +821,14 @@ Examples:
 				userID, err = resolveMyUserID(client, opts)
 			}
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
-			printResult(api.GetFollowers(client, userID, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 1000)
+			printResult(api.GetFollowers(client, userID, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–1000)")
+	addPaginationFlags(cmd)
 	cmd.Flags().StringVar(&targetUser, "of", "", "Username to list followers for (default: you)")
 	addCommonFlags(cmd)
 	return cmd
@@ -704,17 +840,15 @@ func blockCmd(a *auth.Auth) *cobra.Command {
 		Short: "Block a user",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			myID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.BlockUser(client, myID, targetID, opts))
 		},
@@ -729,17 +863,15 @@ func unblockCmd(a *auth.Auth) *cobra.Command {
 		Short: "Unblock a user",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			myID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.UnblockUser(client, myID, targetID, opts))
 		},
@@ -754,17 +886,15 @@ func muteCmd(a *auth.Auth) *cobra.Command {
 		Short: "Mute a user",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			myID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.MuteUser(client, myID, targetID, opts))
 		},
@@ -779,17 +909,15 @@ func unmuteCmd(a *auth.Auth) *cobra.Command {
 		Short: "Unmute a user",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			myID, err := resolveMyUserID(client, opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.UnmuteUser(client, myID, targetID, opts))
 		},
@@ -813,12 +941,11 @@ Examples:
   xurl dm someuser "Hello there"`,
 		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
 			targetID, err := resolveUserID(client, args[0], opts)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "\033[31mError: %v\033[0m\n", err)
-				os.Exit(1)
+				exitOnError(err)
 			}
 			printResult(api.SendDM(client, targetID, args[1], opts))
 		},
@@ -839,12 +966,14 @@ Examples:
   xurl dms -n 25`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			client := newClient(a)
+			client := newClient(cmd, a)
 			opts := baseOpts(cmd)
-			printResult(api.GetDMEvents(client, maxResults, opts))
+			perPage := applyPagination(cmd, &opts, maxResults, 1, 100)
+			printResult(api.GetDMEvents(client, perPage, opts))
 		},
 	}
 	cmd.Flags().IntVarP(&maxResults, "max-results", "n", 10, "Number of results (1–100)")
+	addPaginationFlags(cmd)
 	addCommonFlags(cmd)
 	return cmd
 }
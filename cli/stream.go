@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xdevplatform/xurl/api"
+	"github.com/xdevplatform/xurl/auth"
+)
+
+// CreateStreamCommand creates the stream command and its subcommands
+func CreateStreamCommand(a *auth.Auth) *cobra.Command {
+	streamCmd := &cobra.Command{
+		Use:   "stream",
+		Short: "Manage the filtered stream",
+		Long: `Manage the filtered stream. To read a stream, request it directly:
+
+  xurl /2/tweets/search/stream`,
+	}
+
+	rulesCmd := &cobra.Command{
+		Use:   "rules",
+		Short: "List, add, delete and sync filtered stream rules",
+		Long: `Manage the rules of /2/tweets/search/stream, which decide the posts it
+delivers. Rules use app authentication unless --auth says otherwise.`,
+	}
+	rulesCmd.AddCommand(
+		rulesListCmd(a),
+		rulesAddCmd(a),
+		rulesDeleteCmd(a),
+		rulesSyncCmd(a),
+	)
+	streamCmd.AddCommand(rulesCmd)
+
+	return streamCmd
+}
+
+// addRulesFlags adds the request flags of the rules commands. They are the
+// common shortcut flags, except that --dry-run asks X to validate the change
+// instead of printing a curl command.
+func addRulesFlags(cmd *cobra.Command) {
+	cmd.Flags().String("auth", "app", "Authentication type (app, oauth1, oauth2)")
+	cmd.Flags().StringP("username", "u", "", "Username to act as (OAuth2, or OAuth1 with --auth oauth1)")
+	cmd.Flags().BoolP("verbose", "v", false, "Print verbose request/response info")
+	cmd.Flags().BoolP("trace", "t", false, "Add X-B3-Flags trace header")
+	cmd.Flags().String("format", "", "Output format: json, table, csv, ndjson or yaml")
+	cmd.Flags().Bool("curl", false, "Print the equivalent curl command to stderr before sending")
+	cmd.Flags().Bool("show-auth", false, "Show the real Authorization header in --curl output instead of redacting it")
+}
+
+// rulesOpts returns the request options of a rules command
+func rulesOpts(cmd *cobra.Command) api.RequestOptions {
+	opts := baseOpts(cmd)
+	opts.Curl = nil
+	if printCurl, _ := cmd.Flags().GetBool("curl"); printCurl {
+		showAuth, _ := cmd.Flags().GetBool("show-auth")
+		opts.Curl = &api.CurlOptions{ShowAuth: showAuth}
+	}
+	return opts
+}
+
+func rulesListCmd(a *auth.Auth) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the filtered stream rules",
+		Long: `List the rules of the filtered stream.
+
+Examples:
+  xurl stream rules list
+  xurl stream rules list --format table
+  xurl stream rules list > rules.json    # a file 'stream rules sync' accepts`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient(cmd, a)
+			printResult(api.ListStreamRules(client, rulesOpts(cmd)))
+		},
+	}
+	addRulesFlags(cmd)
+	return cmd
+}
+
+func rulesAddCmd(a *auth.Auth) *cobra.Command {
+	var value, tag string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "add --value RULE [--tag TAG]",
+		Short: "Add a filtered stream rule",
+		Long: `Add a rule to the filtered stream. The tag is included with the posts
+the rule matches.
+
+Examples:
+  xurl stream rules add --value "golang -is:retweet" --tag golang
+  xurl stream rules add --value "from:XDevelopers" --dry-run`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient(cmd, a)
+			rule := api.StreamRule{Value: value, Tag: tag}
+			printResult(api.AddStreamRules(client, []api.StreamRule{rule}, dryRun, rulesOpts(cmd)))
+		},
+	}
+	cmd.Flags().StringVar(&value, "value", "", "The rule, e.g. \"golang -is:retweet\"")
+	cmd.Flags().StringVar(&tag, "tag", "", "A label for the posts the rule matches")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only have X validate the rule")
+	cmd.MarkFlagRequired("value")
+	addRulesFlags(cmd)
+	return cmd
+}
+
+func rulesDeleteCmd(a *auth.Auth) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "delete ID_OR_TAG...",
+		Short: "Delete filtered stream rules by ID or tag",
+		Long: `Delete filtered stream rules. Each argument is a rule ID or a tag; a tag
+deletes every rule that has it.
+
+Examples:
+  xurl stream rules delete 1234567890
+  xurl stream rules delete golang`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient(cmd, a)
+			opts := rulesOpts(cmd)
+
+			response, err := api.ListStreamRules(client, opts)
+			if err != nil {
+				exitOnError(err)
+			}
+			rules, err := api.DecodeStreamRules(response)
+			if err != nil {
+				exitOnError(err)
+			}
+			ids, err := api.ResolveStreamRuleIDs(rules, args)
+			if err != nil {
+				exitOnError(err)
+			}
+			printResult(api.DeleteStreamRules(client, ids, dryRun, opts))
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only have X validate the deletion")
+	addRulesFlags(cmd)
+	return cmd
+}
+
+func rulesSyncCmd(a *auth.Auth) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "sync FILE",
+		Short: "Make the filtered stream rules match a file",
+		Long: `Make the filtered stream rules match a YAML or JSON file: rules in the file
+that the stream lacks are added, and rules the file doesn't list are deleted.
+A rule whose tag changed is deleted and added again. New rules are validated
+with X before anything changes; --dry-run stops there.
+
+The file lists rules, at the top level or under "rules":
+
+  rules:
+    - value: "golang -is:retweet"
+      tag: golang
+    - value: "from:XDevelopers"
+
+The output of 'xurl stream rules list' works too.
+
+Examples:
+  xurl stream rules sync rules.yaml --dry-run
+  xurl stream rules sync rules.yaml`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			rules, err := api.LoadStreamRules(args[0])
+			if err != nil {
+				exitOnError(err)
+			}
+
+			client := newClient(cmd, a)
+			result, err := api.SyncStreamRules(client, rules, dryRun, rulesOpts(cmd))
+			if err != nil {
+				exitOnError(err)
+			}
+
+			for _, rule := range result.Deleted {
+				fmt.Printf("\033[31m- %s\033[0m\n", rule)
+			}
+			for _, rule := range result.Added {
+				fmt.Printf("\033[32m+ %s\033[0m\n", rule)
+			}
+			switch {
+			case len(result.Added) == 0 && len(result.Deleted) == 0:
+				fmt.Println("Stream rules are already in sync")
+			case dryRun:
+				fmt.Printf("Would add %d and delete %d rules (the new rules are valid)\n", len(result.Added), len(result.Deleted))
+			default:
+				fmt.Printf("Added %d and deleted %d rules\n", len(result.Added), len(result.Deleted))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only validate the new rules and show what would change")
+	addRulesFlags(cmd)
+	return cmd
+}
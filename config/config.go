@@ -14,6 +14,8 @@ type Config struct {
 	RedirectURI string
 	AuthURL     string
 	TokenURL    string
+	// OAuth2 token revocation url
+	RevokeURL string
 	// API base url
 	APIBaseURL string
 	// API user info url
@@ -29,6 +31,7 @@ func NewConfig() *Config {
 	redirectURI := getEnvOrDefault("REDIRECT_URI", "http://localhost:8080/callback")
 	authURL := getEnvOrDefault("AUTH_URL", "https://x.com/i/oauth2/authorize")
 	tokenURL := getEnvOrDefault("TOKEN_URL", "https://api.x.com/2/oauth2/token")
+	revokeURL := getEnvOrDefault("REVOKE_URL", "https://api.x.com/2/oauth2/revoke")
 	apiBaseURL := getEnvOrDefault("API_BASE_URL", "https://api.x.com")
 	infoURL := getEnvOrDefault("INFO_URL", fmt.Sprintf("%s/2/users/me", apiBaseURL))
 
@@ -38,6 +41,7 @@ func NewConfig() *Config {
 		RedirectURI:  redirectURI,
 		AuthURL:      authURL,
 		TokenURL:     tokenURL,
+		RevokeURL:    revokeURL,
 		APIBaseURL:   apiBaseURL,
 		InfoURL:      infoURL,
 	}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 const (
@@ -14,12 +16,41 @@ const (
 	ErrTypeJSON          = "JSON Error"
 	ErrTypeAuth          = "Auth Error"
 	ErrTypeTokenStore    = "Token Store Error"
+	ErrTypeRateLimit     = "Rate Limit Error"
+	ErrTypeQuery         = "Query Error"
+	ErrTypeInvalidFormat = "Invalid Format"
+	ErrTypeValidation    = "Validation Error"
 )
 
 type Error struct {
 	Type    string
 	Message string
 	cause   error
+	// RateLimit is set on rate limit errors with the values from the x-rate-limit-* headers
+	RateLimit *RateLimit
+	// StatusCode and Problem are set on errors for X API responses
+	StatusCode int
+	Problem    *Problem
+}
+
+// RateLimit describes the x-rate-limit-* headers returned with a response
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// ResetIn returns how long until the rate limit window resets
+func (r *RateLimit) ResetIn() time.Duration {
+	d := time.Until(r.Reset)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func (r *RateLimit) String() string {
+	return fmt.Sprintf("%d/%d remaining, resets in %s", r.Remaining, r.Limit, r.ResetIn().Round(time.Second))
 }
 
 func (e *Error) Error() string {
@@ -66,22 +97,43 @@ func NewInvalidMethodError(method string) *Error {
 	return NewError(ErrTypeInvalidMethod, fmt.Sprintf("Invalid HTTP method: %s", method), nil)
 }
 
+func NewInvalidFormatError(format string, supported []string) *Error {
+	return NewError(ErrTypeInvalidFormat, fmt.Sprintf("Unknown format %q (use %s)", format, strings.Join(supported, ", ")), nil)
+}
+
 func NewAPIError(data json.RawMessage) *Error {
-	return NewError(ErrTypeAPI, string(data), nil)
+	e := NewError(ErrTypeAPI, string(data), nil)
+	e.Problem = DecodeProblem(data)
+	return e
 }
 
 func NewJSONError(cause error) *Error {
 	return NewError(ErrTypeJSON, cause.Error(), cause)
 }
 
+func NewRateLimitError(data json.RawMessage, rateLimit *RateLimit) *Error {
+	e := NewError(ErrTypeRateLimit, string(data), nil)
+	e.RateLimit = rateLimit
+	e.Problem = DecodeProblem(data)
+	return e
+}
+
 func NewAuthError(message string, cause error) *Error {
 	return NewError(ErrTypeAuth, message, cause)
 }
 
+func NewQueryError(message string, cause error) *Error {
+	return NewError(ErrTypeQuery, message, cause)
+}
+
 func NewTokenStoreError(message string) *Error {
 	return NewError(ErrTypeTokenStore, message, nil)
 }
 
+func NewValidationError(message string) *Error {
+	return NewError(ErrTypeValidation, message, nil)
+}
+
 func IsErrorType(err error, errorType string) bool {
 	var e *Error
 	if ok := errors.As(err, &e); ok {
@@ -92,6 +144,15 @@ func IsErrorType(err error, errorType string) bool {
 
 func IsHTTPError(err error) bool { return IsErrorType(err, ErrTypeHTTP) }
 func IsIOError(err error) bool   { return IsErrorType(err, ErrTypeIO) }
-func IsAPIError(err error) bool  { return IsErrorType(err, ErrTypeAPI) }
-func IsJSONError(err error) bool { return IsErrorType(err, ErrTypeJSON) }
-func IsAuthError(err error) bool { return IsErrorType(err, ErrTypeAuth) }
+
+// IsAPIError reports whether err is an error response from the API, which
+// includes rate limit (429) responses
+func IsAPIError(err error) bool {
+	return IsErrorType(err, ErrTypeAPI) || IsErrorType(err, ErrTypeRateLimit)
+}
+
+func IsJSONError(err error) bool       { return IsErrorType(err, ErrTypeJSON) }
+func IsAuthError(err error) bool       { return IsErrorType(err, ErrTypeAuth) }
+func IsRateLimitError(err error) bool  { return IsErrorType(err, ErrTypeRateLimit) }
+func IsQueryError(err error) bool      { return IsErrorType(err, ErrTypeQuery) }
+func IsValidationError(err error) bool { return IsErrorType(err, ErrTypeValidation) }
@@ -0,0 +1,259 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Exit codes, one per class of error, so scripts can tell failures apart
+const (
+	ExitOK             = 0
+	ExitError          = 1  // anything not covered below
+	ExitUsage          = 2  // bad flags or arguments, or a request that fails validation
+	ExitAuth           = 3  // missing or rejected credentials, or an auth type the endpoint doesn't take
+	ExitForbidden      = 4  // the app or user isn't allowed to do this, e.g. an app not enrolled for the endpoint
+	ExitNotFound       = 5  // the post, user or other resource doesn't exist or isn't visible
+	ExitRateLimit      = 6  // rate limited (HTTP 429)
+	ExitUsageCapped    = 7  // the project's monthly usage cap is used up
+	ExitInvalidRequest = 8  // the API rejected the request's parameters or body
+	ExitServer         = 9  // the API failed (HTTP 5xx)
+	ExitNetwork        = 10 // the request couldn't be sent or its response couldn't be read
+)
+
+// Problem is an error object from the X API, either the whole response body
+// of a failed request or one entry of its errors array. See
+// https://docs.x.com/x-api/fundamentals/response-codes-and-errors
+type Problem struct {
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Status int    `json:"status,omitempty"`
+
+	// Client forbidden problems say why the app was refused
+	Reason             string `json:"reason,omitempty"`
+	RequiredEnrollment string `json:"required_enrollment,omitempty"`
+	RegistrationURL    string `json:"registration_url,omitempty"`
+
+	// Partial errors name the resource they are about
+	ResourceType string `json:"resource_type,omitempty"`
+	ResourceID   string `json:"resource_id,omitempty"`
+	Parameter    string `json:"parameter,omitempty"`
+	Value        any    `json:"value,omitempty"`
+
+	// v1.1 and some invalid request errors use message and code instead
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code,omitempty"`
+
+	Errors []Problem `json:"errors,omitempty"`
+	// Data is set when a request partly succeeded, alongside Errors
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// DecodeProblem reads the problem in an X API response body. It returns nil
+// when the body has no title, detail or errors.
+func DecodeProblem(body []byte) *Problem {
+	var problem Problem
+	if json.Unmarshal(body, &problem) != nil {
+		return nil
+	}
+	if problem.Title == "" && problem.Detail == "" && len(problem.Errors) == 0 {
+		return nil
+	}
+	return &problem
+}
+
+// Name returns the last part of the problem type, e.g. "resource-not-found"
+// for https://api.x.com/2/problems/resource-not-found
+func (p *Problem) Name() string {
+	if i := strings.LastIndex(p.Type, "/problems/"); i >= 0 {
+		return p.Type[i+len("/problems/"):]
+	}
+	return ""
+}
+
+// Partial reports whether a request succeeded for some resources and failed
+// for others
+func (p *Problem) Partial() bool {
+	return len(p.Errors) > 0 && len(p.Data) > 0 && string(p.Data) != "null"
+}
+
+// Failed reports whether a successful response carries only errors, as
+// looking up a single missing post does
+func (p *Problem) Failed() bool {
+	return len(p.Errors) > 0 && !p.Partial() && p.Title == ""
+}
+
+// Summary returns a one line description of the problem
+func (p *Problem) Summary() string {
+	title, detail := p.Title, p.Detail
+	if detail == "" {
+		detail = p.Message
+	}
+	switch {
+	case title != "" && detail != "" && title != detail:
+		return title + ": " + detail
+	case title != "":
+		return title
+	case detail != "":
+		return detail
+	case len(p.Errors) > 0:
+		return p.Errors[0].Summary()
+	}
+	return ""
+}
+
+// main returns the problem that decides how a response is classified: the
+// response itself, or its first error when it has no type of its own
+func (p *Problem) main() *Problem {
+	if p.Type == "" && p.Title == "" && len(p.Errors) > 0 {
+		return &p.Errors[0]
+	}
+	return p
+}
+
+// NewResponseError creates the error for a failed X API response, with the
+// problem in its body decoded. 429 responses are rate limit errors.
+func NewResponseError(statusCode int, data json.RawMessage, rateLimit *RateLimit) *Error {
+	var e *Error
+	if statusCode == http.StatusTooManyRequests {
+		e = NewRateLimitError(data, rateLimit)
+	} else {
+		e = NewAPIError(data)
+	}
+	e.StatusCode = statusCode
+	return e
+}
+
+// ExitCode returns the process exit code for an error
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return ExitError
+	}
+
+	if e.Problem != nil {
+		switch e.Problem.main().Name() {
+		case "client-forbidden", "not-authorized-for-resource", "not-authorized-for-field", "disallowed-resource":
+			return ExitForbidden
+		case "unsupported-authentication":
+			return ExitAuth
+		case "usage-capped":
+			return ExitUsageCapped
+		case "resource-not-found", "resource-unavailable":
+			return ExitNotFound
+		case "invalid-request", "duplicate-rules", "rule-cap", "invalid-rules", "conflict":
+			return ExitInvalidRequest
+		case "streaming-connection":
+			return ExitRateLimit
+		}
+	}
+
+	switch status := e.StatusCode; {
+	case status == http.StatusUnauthorized:
+		return ExitAuth
+	case status == http.StatusForbidden:
+		return ExitForbidden
+	case status == http.StatusNotFound:
+		return ExitNotFound
+	case status == http.StatusTooManyRequests:
+		return ExitRateLimit
+	case status >= 500:
+		return ExitServer
+	case status >= 400:
+		return ExitInvalidRequest
+	}
+
+	switch e.Type {
+	case ErrTypeRateLimit:
+		return ExitRateLimit
+	case ErrTypeAuth, ErrTypeTokenStore:
+		return ExitAuth
+	case ErrTypeHTTP:
+		return ExitNetwork
+	case ErrTypeValidation, ErrTypeInvalidMethod, ErrTypeInvalidFormat, ErrTypeQuery:
+		return ExitUsage
+	}
+	return ExitError
+}
+
+// authTypeNames maps the auth type names used in problem details to --auth values
+var authTypeNames = map[string]string{
+	"OAuth 1.0a User Context":    "oauth1",
+	"OAuth 2.0 User Context":     "oauth2",
+	"OAuth 2.0 Application-Only": "app",
+}
+
+// Hint returns advice on what to do about an error, or "" if there is none
+func Hint(err error) string {
+	var e *Error
+	if !errors.As(err, &e) {
+		return ""
+	}
+
+	var problem *Problem
+	if e.Problem != nil {
+		problem = e.Problem.main()
+	}
+	code := ExitCode(err)
+	switch {
+	case problem != nil && problem.Name() == "client-forbidden":
+		hint := "Your app isn't enrolled for this endpoint: its keys must come from an app attached to a Project with the right access level"
+		if problem.RequiredEnrollment != "" {
+			hint += fmt.Sprintf(" (%s)", problem.RequiredEnrollment)
+		}
+		if problem.RegistrationURL != "" {
+			hint += ". See " + problem.RegistrationURL
+		}
+		return hint
+	case problem != nil && problem.Name() == "unsupported-authentication":
+		var flags []string
+		for name, authType := range authTypeNames {
+			if strings.Contains(problem.Detail, "["+name) || strings.Contains(problem.Detail, ", "+name) {
+				flags = append(flags, "--auth "+authType)
+			}
+		}
+		if len(flags) == 0 {
+			return "This endpoint doesn't accept the auth type used; 'xurl endpoints PATH' lists the ones it does"
+		}
+		sort.Strings(flags)
+		return fmt.Sprintf("This endpoint doesn't accept the auth type used; try %s", strings.Join(flags, " or "))
+	case code == ExitUsageCapped:
+		return "Your Project has used up its monthly usage cap; requests fail until it resets. Check usage with 'xurl /2/usage/tweets --auth app'"
+	case code == ExitNotFound:
+		return "Check the ID or username: the resource may have been deleted, or belong to a suspended or protected account"
+	case code == ExitRateLimit && e.RateLimit != nil:
+		return fmt.Sprintf("Rate limited: %s (use --wait-on-limit to wait automatically)", e.RateLimit)
+	case code == ExitRateLimit:
+		return "Rate limited (use --wait-on-limit or --retries to wait automatically)"
+	case code == ExitAuth && e.StatusCode == http.StatusUnauthorized:
+		return "Your credentials were rejected. Check them with 'xurl auth status'; OAuth 2.0 tokens can be renewed with 'xurl auth oauth2'"
+	}
+	return ""
+}
+
+// reportedError wraps an error whose details have already been printed
+type reportedError struct {
+	err error
+}
+
+func (r *reportedError) Error() string { return "request failed" }
+func (r *reportedError) Unwrap() error { return r.err }
+
+// Reported marks err as already shown to the user, so that callers only
+// need to exit with its ExitCode
+func Reported(err error) error {
+	return &reportedError{err: err}
+}
+
+// IsReported reports whether err was marked with Reported
+func IsReported(err error) bool {
+	var r *reportedError
+	return errors.As(err, &r)
+}
@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeProblem(t *testing.T) {
+	assert.Nil(t, DecodeProblem([]byte(`{"data":{"id":"1"}}`)))
+	assert.Nil(t, DecodeProblem([]byte(`not json`)))
+
+	partial := DecodeProblem([]byte(`{"data":[{"id":"1"}],"errors":[{"title":"Not Found Error","detail":"Could not find tweet with ids: [2].","resource_id":"2","type":"https://api.x.com/2/problems/resource-not-found"}]}`))
+	require.NotNil(t, partial)
+	assert.True(t, partial.Partial())
+	assert.False(t, partial.Failed())
+	assert.Equal(t, "resource-not-found", partial.Errors[0].Name())
+	assert.Equal(t, "Not Found Error: Could not find tweet with ids: [2].", partial.Errors[0].Summary())
+
+	failed := DecodeProblem([]byte(`{"errors":[{"title":"Not Found Error","type":"https://api.x.com/2/problems/resource-not-found"}]}`))
+	require.NotNil(t, failed)
+	assert.True(t, failed.Failed())
+
+	legacy := DecodeProblem([]byte(`{"errors":[{"message":"Could not authenticate you.","code":32}]}`))
+	require.NotNil(t, legacy)
+	assert.Equal(t, "Could not authenticate you.", legacy.Summary())
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", fmt.Errorf("boom"), ExitError},
+		{"validation", NewValidationError("bad path"), ExitUsage},
+		{"network", NewHTTPError(fmt.Errorf("dial tcp: no such host")), ExitNetwork},
+		{"local auth", NewAuthError("no token", nil), ExitAuth},
+		{"unauthorized", apiError(401, `{"title":"Unauthorized","type":"about:blank","status":401}`), ExitAuth},
+		{"client forbidden", apiError(403, `{"title":"Client Forbidden","reason":"client-not-enrolled","type":"https://api.x.com/2/problems/client-forbidden"}`), ExitForbidden},
+		{"unsupported authentication", apiError(403, `{"title":"Unsupported Authentication","type":"https://api.x.com/2/problems/unsupported-authentication"}`), ExitAuth},
+		{"not found", apiError(200, `{"errors":[{"title":"Not Found Error","type":"https://api.x.com/2/problems/resource-not-found"}]}`), ExitNotFound},
+		{"usage capped", apiError(429, `{"title":"UsageCapExceeded","type":"https://api.x.com/2/problems/usage-capped"}`), ExitUsageCapped},
+		{"rate limited", apiError(429, `{"title":"Too Many Requests","type":"about:blank"}`), ExitRateLimit},
+		{"invalid request", apiError(400, `{"title":"Invalid Request","type":"https://api.x.com/2/problems/invalid-request"}`), ExitInvalidRequest},
+		{"server", apiError(503, `{"title":"Service Unavailable"}`), ExitServer},
+		{"wrapped", Reported(fmt.Errorf("append request failed: %w", apiError(404, `{}`))), ExitNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestHint(t *testing.T) {
+	unsupported := apiError(403, `{"title":"Unsupported Authentication","detail":"Authenticating with OAuth 2.0 Application-Only is forbidden for this endpoint.  Supported authentication types are [OAuth 1.0a User Context, OAuth 2.0 User Context].","type":"https://api.x.com/2/problems/unsupported-authentication","status":403}`)
+	assert.Equal(t, "This endpoint doesn't accept the auth type used; try --auth oauth1 or --auth oauth2", Hint(unsupported))
+
+	forbidden := apiError(403, `{"title":"Client Forbidden","reason":"client-not-enrolled","required_enrollment":"Appropriate Level of API Access","registration_url":"https://developer.x.com/en/portal/opt-in","type":"https://api.x.com/2/problems/client-forbidden"}`)
+	assert.Contains(t, Hint(forbidden), "(Appropriate Level of API Access). See https://developer.x.com/en/portal/opt-in")
+
+	assert.Contains(t, Hint(apiError(429, `{"title":"UsageCapExceeded","type":"https://api.x.com/2/problems/usage-capped"}`)), "usage cap")
+	assert.Contains(t, Hint(apiError(429, `{"title":"Too Many Requests"}`)), "--wait-on-limit")
+	assert.Empty(t, Hint(apiError(400, `{"title":"Invalid Request","type":"https://api.x.com/2/problems/invalid-request"}`)))
+	assert.True(t, IsReported(Reported(unsupported)))
+}
+
+func apiError(status int, body string) *Error {
+	return NewResponseError(status, json.RawMessage(body), nil)
+}
@@ -0,0 +1,405 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// Format is an output format for shortcut results
+type Format string
+
+const (
+	JSON   Format = "json"
+	Table  Format = "table"
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	YAML   Format = "yaml"
+)
+
+// Formats lists the supported formats, for help text and completion
+var Formats = []Format{JSON, Table, CSV, NDJSON, YAML}
+
+// maxCellWidth is how many characters of a text column a table shows
+const maxCellWidth = 60
+
+// Parse validates a --format value. An empty value means JSON.
+func Parse(value string) (Format, error) {
+	if value == "" {
+		return JSON, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(value, string(f)) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", xurlErrors.NewInvalidFormatError(value, names)
+}
+
+// Writer renders responses in a format. It can be given several pages of
+// the same list, in which case the CSV header is only written once.
+type Writer struct {
+	w           io.Writer
+	format      Format
+	wroteHeader bool
+}
+
+// NewWriter creates a Writer for format that writes to w
+func NewWriter(w io.Writer, format Format) *Writer {
+	return &Writer{w: w, format: format}
+}
+
+// Write renders one response
+func (fw *Writer) Write(response json.RawMessage) error {
+	switch fw.format {
+	case YAML:
+		return fw.writeYAML(response)
+	case NDJSON:
+		return fw.writeNDJSON(response)
+	case Table, CSV:
+		rows, err := parseRows(response)
+		if err != nil {
+			return err
+		}
+		if fw.format == CSV {
+			return fw.writeCSV(rows)
+		}
+		return fw.writeTable(rows)
+	default:
+		_, err := fw.w.Write(append(bytes.TrimRight(response, "\n"), '\n'))
+		return err
+	}
+}
+
+func (fw *Writer) writeYAML(response json.RawMessage) error {
+	value, err := decode(response)
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(fw.w)
+	encoder.SetIndent(2)
+	if fw.wroteHeader {
+		// Separate pages as YAML documents
+		if _, err := io.WriteString(fw.w, "---\n"); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	}
+	fw.wroteHeader = true
+	if err := encoder.Encode(toYAML(value)); err != nil {
+		return xurlErrors.NewIOError(err)
+	}
+	return encoder.Close()
+}
+
+func (fw *Writer) writeNDJSON(response json.RawMessage) error {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(response, &envelope); err != nil {
+		return xurlErrors.NewJSONError(err)
+	}
+
+	var items []json.RawMessage
+	switch {
+	case len(envelope.Data) == 0:
+		items = []json.RawMessage{response}
+	case json.Unmarshal(envelope.Data, &items) != nil:
+		items = []json.RawMessage{envelope.Data}
+	}
+
+	for _, item := range items {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, item); err != nil {
+			return xurlErrors.NewJSONError(err)
+		}
+		buf.WriteByte('\n')
+		if _, err := fw.w.Write(buf.Bytes()); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	}
+	return nil
+}
+
+func (fw *Writer) writeCSV(rows *rows) error {
+	writer := csv.NewWriter(fw.w)
+	if !fw.wroteHeader {
+		if err := writer.Write(rows.headers()); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+		fw.wroteHeader = true
+	}
+	for _, item := range rows.items {
+		if err := writer.Write(rows.values(item, false)); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (fw *Writer) writeTable(rows *rows) error {
+	if len(rows.items) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(fw.w, 0, 0, 2, ' ', 0)
+	headers := rows.headers()
+	for i, header := range headers {
+		headers[i] = strings.ToUpper(header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, item := range rows.items {
+		fmt.Fprintln(tw, strings.Join(rows.values(item, true), "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return xurlErrors.NewIOError(err)
+	}
+	return nil
+}
+
+// decode parses JSON keeping numbers exact, so IDs and counts aren't
+// turned into floats
+func decode(data json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, xurlErrors.NewJSONError(err)
+	}
+	return value, nil
+}
+
+// toYAML converts json.Number values so they are written as YAML numbers
+// rather than quoted strings
+func toYAML(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = toYAML(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = toYAML(item)
+		}
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// ─── Rows ───────────────────────────────────────────────────────────
+
+// column is one field shown for a resource
+type column struct {
+	header string
+	// wide columns are truncated in tables
+	wide  bool
+	value func(item map[string]any, users map[string]map[string]any) string
+}
+
+// rows are the data items of a response with the columns to show them with
+type rows struct {
+	items   []map[string]any
+	users   map[string]map[string]any
+	columns []column
+}
+
+func (r *rows) headers() []string {
+	headers := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		headers[i] = c.header
+	}
+	return headers
+}
+
+func (r *rows) values(item map[string]any, truncate bool) []string {
+	values := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		value := c.value(item, r.users)
+		if truncate {
+			value = strings.Join(strings.Fields(value), " ")
+			if c.wide {
+				value = truncateRunes(value, maxCellWidth)
+			}
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// parseRows extracts the data items of a response and picks columns for them
+func parseRows(response json.RawMessage) (*rows, error) {
+	value, err := decode(response)
+	if err != nil {
+		return nil, err
+	}
+	envelope, _ := value.(map[string]any)
+
+	r := &rows{users: make(map[string]map[string]any)}
+	switch data := envelope["data"].(type) {
+	case []any:
+		for _, item := range data {
+			if m, ok := item.(map[string]any); ok {
+				r.items = append(r.items, m)
+			}
+		}
+	case map[string]any:
+		r.items = []map[string]any{data}
+	}
+
+	if includes, ok := envelope["includes"].(map[string]any); ok {
+		if users, ok := includes["users"].([]any); ok {
+			for _, user := range users {
+				if m, ok := user.(map[string]any); ok {
+					r.users[str(m["id"])] = m
+				}
+			}
+		}
+	}
+
+	r.columns = columnsFor(r.items)
+	return r, nil
+}
+
+// columnsFor picks columns based on what kind of resource the items are
+func columnsFor(items []map[string]any) []column {
+	if len(items) == 0 {
+		return []column{field("id")}
+	}
+	first := items[0]
+	switch {
+	case has(first, "username"):
+		return userColumns
+	case has(first, "event_type"):
+		return dmColumns
+	case has(first, "text"):
+		return postColumns
+	}
+	return genericColumns(first)
+}
+
+var userColumns = []column{
+	field("id"),
+	field("username"),
+	{header: "name", wide: true, value: func(item map[string]any, _ map[string]map[string]any) string { return str(item["name"]) }},
+	metric("followers", "followers_count"),
+}
+
+var postColumns = []column{
+	field("id"),
+	{header: "author", value: author("author_id")},
+	field("created_at"),
+	{header: "text", wide: true, value: func(item map[string]any, _ map[string]map[string]any) string { return str(item["text"]) }},
+	metric("likes", "like_count"),
+	metric("reposts", "retweet_count"),
+	metric("replies", "reply_count"),
+}
+
+var dmColumns = []column{
+	field("id"),
+	{header: "sender", value: author("sender_id")},
+	field("created_at"),
+	{header: "text", wide: true, value: func(item map[string]any, _ map[string]map[string]any) string { return str(item["text"]) }},
+}
+
+// genericColumns shows every scalar field, id first, for other resources
+func genericColumns(item map[string]any) []column {
+	var keys []string
+	for key, value := range item {
+		switch value.(type) {
+		case map[string]any, []any:
+			continue
+		}
+		if key != "id" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if has(item, "id") {
+		keys = append([]string{"id"}, keys...)
+	}
+
+	columns := make([]column, len(keys))
+	for i, key := range keys {
+		columns[i] = field(key)
+	}
+	return columns
+}
+
+// field shows a top-level field as is
+func field(name string) column {
+	return column{header: name, value: func(item map[string]any, _ map[string]map[string]any) string {
+		return str(item[name])
+	}}
+}
+
+// metric shows a count from public_metrics
+func metric(header, name string) column {
+	return column{header: header, value: func(item map[string]any, _ map[string]map[string]any) string {
+		metrics, _ := item["public_metrics"].(map[string]any)
+		return str(metrics[name])
+	}}
+}
+
+// author resolves a user ID field to a username through includes.users,
+// falling back to the ID when the user wasn't expanded
+func author(idField string) func(map[string]any, map[string]map[string]any) string {
+	return func(item map[string]any, users map[string]map[string]any) string {
+		id := str(item[idField])
+		if user, ok := users[id]; ok && str(user["username"]) != "" {
+			return str(user["username"])
+		}
+		return id
+	}
+}
+
+func has(item map[string]any, key string) bool {
+	_, ok := item[key]
+	return ok
+}
+
+// str renders a JSON value as a cell
+func str(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,99 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+const postsResponse = `{
+	"data": [
+		{"id": "1", "author_id": "10", "created_at": "2024-01-01T00:00:00.000Z", "text": "hello\nworld", "public_metrics": {"like_count": 3, "retweet_count": 1, "reply_count": 0}},
+		{"id": "2", "author_id": "99", "created_at": "2024-01-02T00:00:00.000Z", "text": "golang, again", "public_metrics": {"like_count": 42, "retweet_count": 7, "reply_count": 2}}
+	],
+	"includes": {"users": [{"id": "10", "username": "alice", "name": "Alice"}]},
+	"meta": {"result_count": 2}
+}`
+
+const usersResponse = `{
+	"data": [
+		{"id": "10", "username": "alice", "name": "Alice", "public_metrics": {"followers_count": 1200}},
+		{"id": "20", "username": "bob", "name": "Bob"}
+	]
+}`
+
+func render(t *testing.T, f Format, pages ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, f)
+	for _, page := range pages {
+		require.NoError(t, writer.Write(json.RawMessage(page)))
+	}
+	return buf.String()
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse("")
+	require.NoError(t, err)
+	assert.Equal(t, JSON, f)
+
+	f, err = Parse("CSV")
+	require.NoError(t, err)
+	assert.Equal(t, CSV, f)
+
+	_, err = Parse("xml")
+	require.Error(t, err)
+	assert.True(t, xurlErrors.IsErrorType(err, xurlErrors.ErrTypeInvalidFormat))
+}
+
+func TestCSV(t *testing.T) {
+	t.Run("Posts join includes.users", func(t *testing.T) {
+		out := render(t, CSV, postsResponse)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Equal(t, "id,author,created_at,text,likes,reposts,replies", lines[0])
+		assert.Equal(t, `1,alice,2024-01-01T00:00:00.000Z,"hello`, lines[1], "Text keeps its newline in CSV")
+		assert.Equal(t, `2,99,2024-01-02T00:00:00.000Z,"golang, again",42,7,2`, lines[3], "Unknown authors fall back to the ID")
+	})
+
+	t.Run("Header is written once across pages", func(t *testing.T) {
+		out := render(t, CSV, usersResponse, usersResponse)
+		assert.Equal(t, 1, strings.Count(out, "id,username,name,followers"))
+		assert.Contains(t, out, "10,alice,Alice,1200\n")
+		assert.Contains(t, out, "20,bob,Bob,\n")
+	})
+}
+
+func TestTable(t *testing.T) {
+	out := render(t, Table, postsResponse)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+	assert.Contains(t, lines[0], "AUTHOR")
+	assert.Contains(t, lines[1], "hello world", "Newlines are collapsed in tables")
+}
+
+func TestNDJSON(t *testing.T) {
+	out := render(t, NDJSON, usersResponse)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":"10","username":"alice","name":"Alice","public_metrics":{"followers_count":1200}}`, lines[0])
+
+	out = render(t, NDJSON, `{"data":{"liked":true}}`)
+	assert.Equal(t, "{\"liked\":true}\n", out)
+}
+
+func TestYAML(t *testing.T) {
+	out := render(t, YAML, `{"data":{"id":"10","public_metrics":{"followers_count":1200}}}`)
+	assert.Equal(t, "data:\n  id: \"10\"\n  public_metrics:\n    followers_count: 1200\n", out)
+}
+
+func TestGenericColumns(t *testing.T) {
+	out := render(t, CSV, `{"data":{"following":true,"pending_follow":false}}`)
+	assert.Equal(t, "following,pending_follow\ntrue,false\n", out)
+}
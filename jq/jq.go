@@ -0,0 +1,102 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// Query is a compiled jq expression applied to responses with --jq
+type Query struct {
+	expr string
+	code *gojq.Code
+}
+
+// Compile parses and compiles a jq expression
+func Compile(expr string) (*Query, error) {
+	parsed, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, xurlErrors.NewQueryError(fmt.Sprintf("invalid jq expression %q", expr), err)
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return nil, xurlErrors.NewQueryError(fmt.Sprintf("invalid jq expression %q", expr), err)
+	}
+	return &Query{expr: expr, code: code}, nil
+}
+
+// Run evaluates the query against a JSON document and returns every result.
+// Numbers are decoded as json.Number, so IDs above 2^53 keep their precision.
+func (q *Query) Run(input json.RawMessage) ([]any, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, xurlErrors.NewJSONError(err)
+	}
+
+	var results []any
+	iter := q.code.Run(value)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := result.(error); ok {
+			if halt, ok := err.(*gojq.HaltError); ok && halt.Value() == nil {
+				break
+			}
+			return nil, xurlErrors.NewQueryError(fmt.Sprintf("error evaluating %q", q.expr), err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Apply evaluates the query against a JSON document and writes one result
+// per line. Strings are written without quotes, like jq -r, so results can
+// be used directly in shell scripts; other values are written as compact JSON.
+func (q *Query) Apply(input json.RawMessage, w io.Writer) error {
+	results, err := q.Run(input)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		line, err := formatResult(result)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return xurlErrors.NewIOError(err)
+		}
+	}
+	return nil
+}
+
+// Filter evaluates the query and returns the lines Apply would write
+func (q *Query) Filter(input json.RawMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := q.Apply(input, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatResult renders a single result for output
+func formatResult(result any) (string, error) {
+	if s, ok := result.(string); ok {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(result); err != nil {
+		return "", xurlErrors.NewJSONError(err)
+	}
+	return string(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
@@ -0,0 +1,78 @@
+package jq
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+const searchResponse = `{
+	"data": [
+		{"id": "1", "text": "hello", "author_id": "10", "public_metrics": {"like_count": 3}},
+		{"id": "2", "text": "golang", "author_id": "20", "public_metrics": {"like_count": 42}}
+	],
+	"meta": {"result_count": 2}
+}`
+
+func apply(t *testing.T, expr, input string) string {
+	t.Helper()
+	query, err := Compile(expr)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, query.Apply(json.RawMessage(input), &buf))
+	return buf.String()
+}
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{"Path selection", ".meta.result_count", "2\n"},
+		{"Array iteration", ".data[].id", "1\n2\n"},
+		{"Select", `.data[] | select(.public_metrics.like_count > 10) | .text`, "golang\n"},
+		{"Map", ".data | map(.author_id)", `["10","20"]` + "\n"},
+		{"Object construction", `.data[0] | {id, text}`, `{"id":"1","text":"hello"}` + "\n"},
+		{"Missing path", ".includes.users", "null\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, apply(t, tc.expr, searchResponse))
+		})
+	}
+}
+
+func TestLargeIntegers(t *testing.T) {
+	// Above 2^53, where a float64 can't hold every integer
+	input := `{"data": [{"id": 1849302958673920001, "n": 1}, {"id": 1849302958673920003, "n": 2}]}`
+
+	assert.Equal(t, "1849302958673920001\n1849302958673920003\n", apply(t, ".data[].id", input))
+	assert.Equal(t, `{"id":1849302958673920003}`+"\n", apply(t, ".data[] | select(.id > 1849302958673920001) | {id}", input))
+	assert.Equal(t, "1849302958673920002\n", apply(t, ".data[0].id + 1", input))
+	assert.Equal(t, "3\n", apply(t, "[.data[].n] | add", input))
+}
+
+func TestCompileError(t *testing.T) {
+	_, err := Compile(".data[")
+	require.Error(t, err)
+	assert.True(t, xurlErrors.IsQueryError(err), "Expected query error")
+}
+
+func TestRunError(t *testing.T) {
+	query, err := Compile(".data.id")
+	require.NoError(t, err)
+
+	_, err = query.Run(json.RawMessage(searchResponse))
+	require.Error(t, err, "Indexing an array with a key should fail")
+	assert.True(t, xurlErrors.IsQueryError(err))
+
+	_, err = query.Run(json.RawMessage("not json"))
+	assert.True(t, xurlErrors.IsJSONError(err))
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/xdevplatform/xurl/auth"
 	"github.com/xdevplatform/xurl/cli"
 	"github.com/xdevplatform/xurl/config"
+	xurlErrors "github.com/xdevplatform/xurl/errors"
 )
 
 func main() {
@@ -17,9 +21,19 @@ func main() {
 	// Create the root command
 	rootCmd := cli.CreateRootCommand(config, auth)
 
-	// Execute the command
-	if err := rootCmd.Execute(); err != nil {
+	// Cancel in-flight work on Ctrl+C or SIGTERM so streams and uploads can
+	// stop cleanly. A second signal falls back to the default and exits.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Execute the command. Commands exit on their own errors, so what is
+	// left are bad flags and arguments.
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(xurlErrors.ExitUsage)
 	}
 }
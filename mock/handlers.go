@@ -0,0 +1,425 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (s *Server) handleCreatePost(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Text  string `json:"text"`
+		Reply *struct {
+			InReplyToTweetID string `json:"in_reply_to_tweet_id"`
+		} `json:"reply"`
+		QuoteTweetID string `json:"quote_tweet_id"`
+		Media        *struct {
+			MediaIDs []string `json:"media_ids"`
+		} `json:"media"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Text == "" && body.Media == nil {
+		writeInvalidRequest(w, "$.text: is missing but it is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var refs []referencedPost
+	if body.Reply != nil {
+		if _, ok := s.posts[body.Reply.InReplyToTweetID]; !ok {
+			writeInvalidRequest(w, "The Post you are replying to does not exist.")
+			return
+		}
+		refs = append(refs, referencedPost{Type: "replied_to", ID: body.Reply.InReplyToTweetID})
+	}
+	if body.QuoteTweetID != "" {
+		if _, ok := s.posts[body.QuoteTweetID]; !ok {
+			writeInvalidRequest(w, "The Post you are quoting does not exist.")
+			return
+		}
+		refs = append(refs, referencedPost{Type: "quoted", ID: body.QuoteTweetID})
+	}
+
+	var keys []string
+	if body.Media != nil {
+		for _, id := range body.Media.MediaIDs {
+			m, ok := s.media[id]
+			if !ok || !m.Finalized {
+				writeInvalidRequest(w, fmt.Sprintf("Your media IDs are invalid: %s", id))
+				return
+			}
+			keys = append(keys, m.Key)
+		}
+	}
+
+	p := s.createPost(s.me().ID, body.Text, refs)
+	if len(keys) > 0 {
+		p.Attachments = &attachments{MediaKeys: keys}
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": map[string]any{
+		"id":                     p.ID,
+		"text":                   p.Text,
+		"edit_history_tweet_ids": p.EditHistoryTweets,
+	}})
+}
+
+func (s *Server) handleGetPost(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	p, ok := s.posts[id]
+	if !ok {
+		writeNotFound(w, "tweet", "id", id)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":     s.postJSON(p),
+		"includes": map[string]any{"users": s.authors([]string{id})},
+	})
+}
+
+func (s *Server) handleDeletePost(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	p, ok := s.posts[id]
+	if !ok {
+		writeNotFound(w, "tweet", "id", id)
+		return
+	}
+	if p.AuthorID != s.me().ID {
+		writeProblem(w, http.StatusForbidden, "Forbidden", "You are not allowed to delete a Tweet that you do not own.", "about:blank")
+		return
+	}
+	delete(s.posts, id)
+	s.order = slices.DeleteFunc(s.order, func(other string) bool { return other == id })
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"deleted": true}})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		writeInvalidRequest(w, "The `query` query parameter can not be empty")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writePosts(w, r, s.newestFirst(func(p *post) bool {
+		return matchQuery(query, p.Text, s.users[p.AuthorID].Username)
+	}), 100)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.userJSON(s.me())})
+}
+
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	u, ok := s.users[id]
+	if !ok {
+		writeNotFound(w, "user", "id", id)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.userJSON(u)})
+}
+
+// handleUserByUsername looks up a user, creating unknown usernames so any
+// handle works in demos
+func (s *Server) handleUserByUsername(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	username := r.PathValue("username")
+	id, ok := s.usernames[strings.ToLower(username)]
+	if !ok {
+		id = s.addUser(username, username).ID
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.userJSON(s.users[id])})
+}
+
+func (s *Server) handleUserPosts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	s.writePosts(w, r, s.newestFirst(func(p *post) bool { return p.AuthorID == id }), 100)
+}
+
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	following := s.following[id]
+	muted := s.muting[id]
+	s.writePosts(w, r, s.newestFirst(func(p *post) bool {
+		return (p.AuthorID == id || slices.Contains(following, p.AuthorID)) && !slices.Contains(muted, p.AuthorID)
+	}), 100)
+}
+
+func (s *Server) handleMentions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[r.PathValue("id")]
+	if !ok {
+		writeNotFound(w, "user", "id", r.PathValue("id"))
+		return
+	}
+	mention := "@" + strings.ToLower(u.Username)
+	s.writePosts(w, r, s.newestFirst(func(p *post) bool {
+		return strings.Contains(strings.ToLower(p.Text), mention)
+	}), 100)
+}
+
+// addRelation handles POSTs that add a post or user ID, read from field,
+// to one of the user's lists
+func (s *Server) addRelation(lists map[string][]string, field, result string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		target := body[field]
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if !s.exists(field, target) {
+			writeInvalidRequest(w, fmt.Sprintf("The `%s` field must reference an existing resource: %s", field, target))
+			return
+		}
+		id := r.PathValue("id")
+		if !slices.Contains(lists[id], target) {
+			lists[id] = append(lists[id], target)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{result: true}})
+	}
+}
+
+// removeRelation handles DELETEs that remove a post or user ID from one of
+// the user's lists
+func (s *Server) removeRelation(lists map[string][]string, result string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		id, target := r.PathValue("id"), r.PathValue("target")
+		lists[id] = slices.DeleteFunc(lists[id], func(other string) bool { return other == target })
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{result: false}})
+	}
+}
+
+// listPosts lists the posts in one of the user's lists, newest first
+func (s *Server) listPosts(lists map[string][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		ids := slices.Clone(lists[r.PathValue("id")])
+		slices.Reverse(ids)
+		ids = slices.DeleteFunc(ids, func(id string) bool { return s.posts[id] == nil })
+		s.writePosts(w, r, ids, 100)
+	}
+}
+
+func (s *Server) handleFollowing(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeUsers(w, r, s.following[r.PathValue("id")])
+}
+
+func (s *Server) handleFollowers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	var followers []string
+	for follower, ids := range s.following {
+		if slices.Contains(ids, id) {
+			followers = append(followers, follower)
+		}
+	}
+	slices.Sort(followers)
+	s.writeUsers(w, r, followers)
+}
+
+func (s *Server) handleSendDM(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.Text == "" {
+		writeInvalidRequest(w, "$.text: is missing but it is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participant := r.PathValue("id")
+	if s.users[participant] == nil {
+		writeInvalidRequest(w, fmt.Sprintf("The participant %s does not exist", participant))
+		return
+	}
+	me := s.me().ID
+	pair := []string{me, participant}
+	slices.Sort(pair)
+
+	event := &dmEvent{
+		ID:               s.id(),
+		EventType:        "MessageCreate",
+		Text:             body.Text,
+		SenderID:         me,
+		DMConversationID: strings.Join(pair, "-"),
+		CreatedAt:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+	}
+	s.dmEvents = append(s.dmEvents, event)
+	writeJSON(w, http.StatusCreated, map[string]any{"data": map[string]any{
+		"dm_conversation_id": event.DMConversationID,
+		"dm_event_id":        event.ID,
+	}})
+}
+
+func (s *Server) handleDMEvents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := slices.Clone(s.dmEvents)
+	slices.Reverse(events)
+	ids := make([]string, len(events))
+	byID := make(map[string]*dmEvent)
+	for i, event := range events {
+		ids[i] = event.ID
+		byID[event.ID] = event
+	}
+
+	page, meta := paginate(r, ids, 100)
+	data := make([]*dmEvent, 0, len(page))
+	senders := make(map[string]bool)
+	var users []map[string]any
+	for _, id := range page {
+		event := byID[id]
+		data = append(data, event)
+		if !senders[event.SenderID] {
+			senders[event.SenderID] = true
+			users = append(users, s.userJSON(s.users[event.SenderID]))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data, "includes": map[string]any{"users": users}, "meta": meta})
+}
+
+// exists reports whether the post or user ID a relation field names exists.
+// Callers hold s.mu.
+func (s *Server) exists(field, id string) bool {
+	if field == "tweet_id" {
+		return s.posts[id] != nil
+	}
+	return s.users[id] != nil
+}
+
+// newestFirst lists the IDs of posts matching keep, newest first. Callers
+// hold s.mu.
+func (s *Server) newestFirst(keep func(p *post) bool) []string {
+	var ids []string
+	for i := len(s.order) - 1; i >= 0; i-- {
+		if p := s.posts[s.order[i]]; p != nil && keep(p) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+// authors returns the users who wrote the given posts. Callers hold s.mu.
+func (s *Server) authors(ids []string) []map[string]any {
+	seen := make(map[string]bool)
+	users := []map[string]any{}
+	for _, id := range ids {
+		authorID := s.posts[id].AuthorID
+		if !seen[authorID] {
+			seen[authorID] = true
+			users = append(users, s.userJSON(s.users[authorID]))
+		}
+	}
+	return users
+}
+
+// writePosts writes a page of posts with their authors. Callers hold s.mu.
+func (s *Server) writePosts(w http.ResponseWriter, r *http.Request, ids []string, limit int) {
+	page, meta := paginate(r, ids, limit)
+	if len(page) == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"meta": meta})
+		return
+	}
+
+	data := make([]map[string]any, 0, len(page))
+	for _, id := range page {
+		data = append(data, s.postJSON(s.posts[id]))
+	}
+	meta["newest_id"] = page[0]
+	meta["oldest_id"] = page[len(page)-1]
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":     data,
+		"includes": map[string]any{"users": s.authors(page)},
+		"meta":     meta,
+	})
+}
+
+// writeUsers writes a page of users. Callers hold s.mu.
+func (s *Server) writeUsers(w http.ResponseWriter, r *http.Request, ids []string) {
+	page, meta := paginate(r, ids, 1000)
+	if len(page) == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"meta": meta})
+		return
+	}
+
+	data := make([]map[string]any, 0, len(page))
+	for _, id := range page {
+		data = append(data, s.userJSON(s.users[id]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data, "meta": meta})
+}
+
+// matchQuery is a small subset of the search query language: every term must
+// appear in the text, except -term which must not, and from:username
+// matches the author
+func matchQuery(query, text, author string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		negate := strings.HasPrefix(term, "-")
+		term = strings.Trim(strings.TrimPrefix(term, "-"), `"()`)
+
+		var match bool
+		switch {
+		case term == "" || term == "or":
+			continue
+		case strings.HasPrefix(term, "from:"):
+			match = strings.EqualFold(strings.TrimPrefix(term, "from:"), author)
+		case strings.HasPrefix(term, "is:") || strings.HasPrefix(term, "has:") || strings.HasPrefix(term, "lang:"):
+			// Operators on metadata the mock doesn't track match everything
+			match = !negate
+		default:
+			match = strings.Contains(text, term)
+		}
+		if match == negate {
+			return false
+		}
+	}
+	return true
+}
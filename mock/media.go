@@ -0,0 +1,173 @@
+package mock
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSegmentBytes mirrors the API's limit on a single APPEND segment
+const maxSegmentBytes = 5 * 1024 * 1024
+
+func (s *Server) handleMediaInit(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TotalBytes    int64  `json:"total_bytes"`
+		MediaType     string `json:"media_type"`
+		MediaCategory string `json:"media_category"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.TotalBytes <= 0 || body.MediaType == "" {
+		writeInvalidRequest(w, "total_bytes and media_type are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.newMedia(body.MediaType, body.MediaCategory, body.TotalBytes)
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"id":                 m.ID,
+		"media_key":          m.Key,
+		"expires_after_secs": 86400,
+	}})
+}
+
+func (s *Server) handleMediaAppend(w http.ResponseWriter, r *http.Request) {
+	size, ok := segmentSize(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[r.PathValue("id")]
+	if !ok {
+		writeInvalidRequest(w, fmt.Sprintf("Unknown media_id %s", r.PathValue("id")))
+		return
+	}
+	if m.Finalized {
+		writeInvalidRequest(w, "Media upload was already finalized")
+		return
+	}
+	m.Received += size
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"expires_at": time.Now().Add(24 * time.Hour).Unix(),
+	}})
+}
+
+func (s *Server) handleMediaFinalize(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[r.PathValue("id")]
+	if !ok {
+		writeInvalidRequest(w, fmt.Sprintf("Unknown media_id %s", r.PathValue("id")))
+		return
+	}
+	if m.Received != m.TotalBytes {
+		writeInvalidRequest(w, fmt.Sprintf("Segments total %d bytes, but total_bytes was %d", m.Received, m.TotalBytes))
+		return
+	}
+	m.Finalized = true
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.mediaJSON(m)})
+}
+
+// handleSimpleUpload accepts a whole file in one multipart request
+func (s *Server) handleSimpleUpload(w http.ResponseWriter, r *http.Request) {
+	size, ok := segmentSize(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.newMedia("application/octet-stream", r.FormValue("media_category"), size)
+	m.Received = size
+	m.Finalized = true
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.mediaJSON(m)})
+}
+
+// handleMediaStatus reports processing progress. Videos go from pending to
+// in_progress to succeeded over successive checks.
+func (s *Server) handleMediaStatus(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if command := query.Get("command"); command != "" && command != "STATUS" {
+		writeInvalidRequest(w, fmt.Sprintf("Unsupported command %s", command))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[query.Get("media_id")]
+	if !ok {
+		writeInvalidRequest(w, fmt.Sprintf("Unknown media_id %s", query.Get("media_id")))
+		return
+	}
+	m.checks++
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.mediaJSON(m)})
+}
+
+// newMedia registers an upload. Callers hold s.mu.
+func (s *Server) newMedia(mediaType, category string, totalBytes int64) *media {
+	id := s.id()
+	prefix := 3
+	if strings.HasPrefix(mediaType, "video/") || strings.Contains(category, "video") {
+		prefix = 7
+	}
+	m := &media{ID: id, Key: strconv.Itoa(prefix) + "_" + id, TotalBytes: totalBytes, MediaCategory: category}
+	s.media[id] = m
+	return m
+}
+
+// mediaJSON describes an upload, with processing info for videos. Callers
+// hold s.mu.
+func (s *Server) mediaJSON(m *media) map[string]any {
+	data := map[string]any{
+		"id":                 m.ID,
+		"media_key":          m.Key,
+		"size":               m.TotalBytes,
+		"expires_after_secs": 86400,
+	}
+	if !strings.Contains(m.MediaCategory, "video") {
+		return data
+	}
+
+	info := map[string]any{"state": "succeeded", "progress_percent": 100}
+	switch m.checks {
+	case 0:
+		info = map[string]any{"state": "pending", "check_after_secs": 1}
+	case 1:
+		info = map[string]any{"state": "in_progress", "check_after_secs": 1, "progress_percent": 50}
+	}
+	data["processing_info"] = info
+	return data
+}
+
+// segmentSize reads the media part of a multipart upload and returns its size
+func segmentSize(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if err := r.ParseMultipartForm(maxSegmentBytes); err != nil {
+		writeInvalidRequest(w, fmt.Sprintf("Expected a multipart/form-data body: %v", err))
+		return 0, false
+	}
+	if value := r.FormValue("media"); value != "" {
+		return int64(len(value)), true
+	}
+	file, _, err := r.FormFile("media")
+	if err != nil {
+		writeInvalidRequest(w, "The media field is required")
+		return 0, false
+	}
+	defer file.Close()
+	size, err := io.Copy(io.Discard, file)
+	if err != nil {
+		writeInvalidRequest(w, fmt.Sprintf("Could not read media: %v", err))
+		return 0, false
+	}
+	return size, true
+}
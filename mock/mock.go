@@ -0,0 +1,389 @@
+// Package mock is an in-memory stand-in for the part of the X API v2 that
+// xurl's shortcut commands use, for CI and demos without credentials.
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MeUsername is the username of the authenticated user, whatever credentials
+// a request carries
+const MeUsername = "xurl_mock"
+
+// Server is an http.Handler serving a mock X API. Its state lives in memory
+// and is lost when the process exits.
+type Server struct {
+	mux *http.ServeMux
+	// Heartbeat is the interval between keep-alive newlines on streams
+	Heartbeat time.Duration
+
+	mu        sync.Mutex
+	nextID    int64
+	users     map[string]*user
+	usernames map[string]string
+	posts     map[string]*post
+	order     []string
+	likes     map[string][]string
+	retweets  map[string][]string
+	bookmarks map[string][]string
+	following map[string][]string
+	blocking  map[string][]string
+	muting    map[string][]string
+	dmEvents  []*dmEvent
+	media     map[string]*media
+	rules     []*rule
+	streams   map[chan []byte]bool
+}
+
+type user struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+	Verified    bool   `json:"verified"`
+}
+
+type post struct {
+	ID                string           `json:"id"`
+	Text              string           `json:"text"`
+	AuthorID          string           `json:"author_id"`
+	CreatedAt         string           `json:"created_at"`
+	ConversationID    string           `json:"conversation_id"`
+	InReplyToUserID   string           `json:"in_reply_to_user_id,omitempty"`
+	ReferencedTweets  []referencedPost `json:"referenced_tweets,omitempty"`
+	Attachments       *attachments     `json:"attachments,omitempty"`
+	EditHistoryTweets []string         `json:"edit_history_tweet_ids"`
+}
+
+type referencedPost struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type attachments struct {
+	MediaKeys []string `json:"media_keys"`
+}
+
+type dmEvent struct {
+	ID               string `json:"id"`
+	EventType        string `json:"event_type"`
+	Text             string `json:"text"`
+	SenderID         string `json:"sender_id"`
+	DMConversationID string `json:"dm_conversation_id"`
+	CreatedAt        string `json:"created_at"`
+}
+
+type media struct {
+	ID            string
+	Key           string
+	TotalBytes    int64
+	Received      int64
+	MediaCategory string
+	Finalized     bool
+	// checks counts STATUS requests, which move processing along
+	checks int
+}
+
+type rule struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// NewServer creates a mock API seeded with the authenticated user and a few
+// other accounts and posts
+func NewServer() *Server {
+	s := &Server{
+		mux:       http.NewServeMux(),
+		Heartbeat: 20 * time.Second,
+		nextID:    1900000000000000000,
+		users:     make(map[string]*user),
+		usernames: make(map[string]string),
+		posts:     make(map[string]*post),
+		likes:     make(map[string][]string),
+		retweets:  make(map[string][]string),
+		bookmarks: make(map[string][]string),
+		following: make(map[string][]string),
+		blocking:  make(map[string][]string),
+		muting:    make(map[string][]string),
+		media:     make(map[string]*media),
+		streams:   make(map[chan []byte]bool),
+	}
+
+	me := s.addUser(MeUsername, "xurl mock user")
+	dev := s.addUser("XDevelopers", "Developers")
+	s.addUser("jack", "jack")
+	s.createPost(dev.ID, "Welcome to the xurl mock API! Everything here lives in memory.", nil)
+	s.createPost(dev.ID, "Try `xurl post \"hello\"` and then `xurl timeline`.", nil)
+	s.following[me.ID] = []string{dev.ID}
+
+	s.routes()
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("POST /2/tweets", s.handleCreatePost)
+	s.mux.HandleFunc("GET /2/tweets/{id}", s.handleGetPost)
+	s.mux.HandleFunc("DELETE /2/tweets/{id}", s.handleDeletePost)
+	s.mux.HandleFunc("GET /2/tweets/search/recent", s.handleSearch)
+	s.mux.HandleFunc("GET /2/tweets/search/stream", s.handleStream)
+	s.mux.HandleFunc("GET /2/tweets/search/stream/rules", s.handleGetRules)
+	s.mux.HandleFunc("POST /2/tweets/search/stream/rules", s.handleUpdateRules)
+
+	s.mux.HandleFunc("GET /2/users/me", s.handleMe)
+	s.mux.HandleFunc("GET /2/users/{id}", s.handleGetUser)
+	s.mux.HandleFunc("GET /2/users/by/username/{username}", s.handleUserByUsername)
+	s.mux.HandleFunc("GET /2/users/{id}/tweets", s.handleUserPosts)
+	s.mux.HandleFunc("GET /2/users/{id}/timelines/reverse_chronological", s.handleTimeline)
+	s.mux.HandleFunc("GET /2/users/{id}/mentions", s.handleMentions)
+
+	s.mux.HandleFunc("POST /2/users/{id}/likes", s.addRelation(s.likes, "tweet_id", "liked"))
+	s.mux.HandleFunc("DELETE /2/users/{id}/likes/{target}", s.removeRelation(s.likes, "liked"))
+	s.mux.HandleFunc("GET /2/users/{id}/liked_tweets", s.listPosts(s.likes))
+	s.mux.HandleFunc("POST /2/users/{id}/retweets", s.addRelation(s.retweets, "tweet_id", "retweeted"))
+	s.mux.HandleFunc("DELETE /2/users/{id}/retweets/{target}", s.removeRelation(s.retweets, "retweeted"))
+	s.mux.HandleFunc("POST /2/users/{id}/bookmarks", s.addRelation(s.bookmarks, "tweet_id", "bookmarked"))
+	s.mux.HandleFunc("DELETE /2/users/{id}/bookmarks/{target}", s.removeRelation(s.bookmarks, "bookmarked"))
+	s.mux.HandleFunc("GET /2/users/{id}/bookmarks", s.listPosts(s.bookmarks))
+	s.mux.HandleFunc("POST /2/users/{id}/following", s.addRelation(s.following, "target_user_id", "following"))
+	s.mux.HandleFunc("DELETE /2/users/{id}/following/{target}", s.removeRelation(s.following, "following"))
+	s.mux.HandleFunc("GET /2/users/{id}/following", s.handleFollowing)
+	s.mux.HandleFunc("GET /2/users/{id}/followers", s.handleFollowers)
+	s.mux.HandleFunc("POST /2/users/{id}/blocking", s.addRelation(s.blocking, "target_user_id", "blocking"))
+	s.mux.HandleFunc("DELETE /2/users/{id}/blocking/{target}", s.removeRelation(s.blocking, "blocking"))
+	s.mux.HandleFunc("POST /2/users/{id}/muting", s.addRelation(s.muting, "target_user_id", "muting"))
+	s.mux.HandleFunc("DELETE /2/users/{id}/muting/{target}", s.removeRelation(s.muting, "muting"))
+
+	s.mux.HandleFunc("POST /2/dm_conversations/with/{id}/messages", s.handleSendDM)
+	s.mux.HandleFunc("GET /2/dm_events", s.handleDMEvents)
+
+	s.mux.HandleFunc("POST /2/media/upload", s.handleSimpleUpload)
+	s.mux.HandleFunc("POST /2/media/upload/initialize", s.handleMediaInit)
+	s.mux.HandleFunc("POST /2/media/upload/{id}/append", s.handleMediaAppend)
+	s.mux.HandleFunc("POST /2/media/upload/{id}/finalize", s.handleMediaFinalize)
+	s.mux.HandleFunc("GET /2/media/upload", s.handleMediaStatus)
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, "Not Found Error", fmt.Sprintf("The mock API does not implement %s %s", r.Method, r.URL.Path), "about:blank")
+	})
+}
+
+// id returns a new snowflake-like ID. Callers hold s.mu.
+func (s *Server) id() string {
+	s.nextID++
+	return strconv.FormatInt(s.nextID, 10)
+}
+
+// me returns the authenticated user. Callers hold s.mu.
+func (s *Server) me() *user {
+	return s.users[s.usernames[strings.ToLower(MeUsername)]]
+}
+
+// addUser creates a user. Callers hold s.mu or own s.
+func (s *Server) addUser(username, name string) *user {
+	u := &user{
+		ID:          s.id(),
+		Username:    username,
+		Name:        name,
+		Description: "A mock account",
+		CreatedAt:   "2020-01-01T00:00:00.000Z",
+	}
+	s.users[u.ID] = u
+	s.usernames[strings.ToLower(username)] = u.ID
+	return u
+}
+
+// createPost stores a new post and sends it to matching streams. Callers
+// hold s.mu or own s.
+func (s *Server) createPost(authorID, text string, refs []referencedPost) *post {
+	p := &post{
+		ID:               s.id(),
+		Text:             text,
+		AuthorID:         authorID,
+		CreatedAt:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		ReferencedTweets: refs,
+	}
+	p.ConversationID = p.ID
+	p.EditHistoryTweets = []string{p.ID}
+	for _, ref := range refs {
+		if parent, ok := s.posts[ref.ID]; ok && ref.Type == "replied_to" {
+			p.ConversationID = parent.ConversationID
+			p.InReplyToUserID = parent.AuthorID
+		}
+	}
+	s.posts[p.ID] = p
+	s.order = append(s.order, p.ID)
+	s.publish(p)
+	return p
+}
+
+// postJSON adds public metrics computed from the current state to a post.
+// Callers hold s.mu.
+func (s *Server) postJSON(p *post) map[string]any {
+	data, _ := json.Marshal(p)
+	var out map[string]any
+	json.Unmarshal(data, &out)
+
+	metrics := map[string]int{"like_count": 0, "retweet_count": 0, "reply_count": 0, "quote_count": 0, "bookmark_count": 0, "impression_count": 0}
+	for _, ids := range s.likes {
+		if slices.Contains(ids, p.ID) {
+			metrics["like_count"]++
+		}
+	}
+	for _, ids := range s.retweets {
+		if slices.Contains(ids, p.ID) {
+			metrics["retweet_count"]++
+		}
+	}
+	for _, ids := range s.bookmarks {
+		if slices.Contains(ids, p.ID) {
+			metrics["bookmark_count"]++
+		}
+	}
+	for _, other := range s.posts {
+		for _, ref := range other.ReferencedTweets {
+			if ref.ID != p.ID {
+				continue
+			}
+			switch ref.Type {
+			case "replied_to":
+				metrics["reply_count"]++
+			case "quoted":
+				metrics["quote_count"]++
+			}
+		}
+	}
+	out["public_metrics"] = metrics
+	return out
+}
+
+// userJSON adds public metrics computed from the current state to a user.
+// Callers hold s.mu.
+func (s *Server) userJSON(u *user) map[string]any {
+	followers := 0
+	for _, ids := range s.following {
+		if slices.Contains(ids, u.ID) {
+			followers++
+		}
+	}
+	tweets := 0
+	for _, p := range s.posts {
+		if p.AuthorID == u.ID {
+			tweets++
+		}
+	}
+	return map[string]any{
+		"id":          u.ID,
+		"username":    u.Username,
+		"name":        u.Name,
+		"description": u.Description,
+		"created_at":  u.CreatedAt,
+		"verified":    u.Verified,
+		"public_metrics": map[string]int{
+			"followers_count": followers,
+			"following_count": len(s.following[u.ID]),
+			"tweet_count":     tweets,
+			"listed_count":    0,
+			"like_count":      len(s.likes[u.ID]),
+		},
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("x-rate-limit-limit", "900")
+	w.Header().Set("x-rate-limit-remaining", "899")
+	w.Header().Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(15*time.Minute).Unix(), 10))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeProblem writes an X API problem response
+func writeProblem(w http.ResponseWriter, status int, title, detail, problemType string) {
+	writeJSON(w, status, map[string]any{
+		"title":  title,
+		"detail": detail,
+		"type":   problemType,
+		"status": status,
+	})
+}
+
+// writeInvalidRequest writes the 400 response X returns for bad parameters
+func writeInvalidRequest(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{
+		"errors": []map[string]any{{"message": message}},
+		"title":  "Invalid Request",
+		"detail": "One or more parameters to your request was invalid.",
+		"type":   "https://api.twitter.com/2/problems/invalid-request",
+	})
+}
+
+// writeNotFound writes the partial error X returns for a missing resource
+func writeNotFound(w http.ResponseWriter, resourceType, parameter, id string) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"errors": []map[string]any{{
+			"value":         id,
+			"detail":        fmt.Sprintf("Could not find %s with %s: [%s].", resourceType, parameter, id),
+			"title":         "Not Found Error",
+			"resource_type": resourceType,
+			"parameter":     parameter,
+			"resource_id":   id,
+			"type":          "https://api.twitter.com/2/problems/resource-not-found",
+		}},
+	})
+}
+
+// decodeBody reads a JSON request body into v
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeInvalidRequest(w, fmt.Sprintf("Request body is not valid JSON: %v", err))
+		return false
+	}
+	return true
+}
+
+// maxResults reads max_results, defaulting to 10 and capped at limit
+func maxResults(r *http.Request, limit int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get("max_results"))
+	if err != nil || n <= 0 {
+		return 10
+	}
+	return min(n, limit)
+}
+
+// paginate returns one page of ids and the meta object describing it. The
+// page token is the offset of the page, accepted as next_token or
+// pagination_token.
+func paginate(r *http.Request, ids []string, limit int) ([]string, map[string]any) {
+	token := r.URL.Query().Get("pagination_token")
+	if token == "" {
+		token = r.URL.Query().Get("next_token")
+	}
+	offset, _ := strconv.Atoi(token)
+	offset = max(0, min(offset, len(ids)))
+	end := min(offset+maxResults(r, limit), len(ids))
+
+	page := ids[offset:end]
+	meta := map[string]any{"result_count": len(page)}
+	if end < len(ids) {
+		meta["next_token"] = strconv.Itoa(end)
+	}
+	if offset > 0 {
+		meta["previous_token"] = strconv.Itoa(max(0, offset-maxResults(r, limit)))
+	}
+	return page, meta
+}
@@ -0,0 +1,151 @@
+package mock
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request(t *testing.T, server *httptest.Server, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var out map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	return resp.StatusCode, out
+}
+
+func TestPostsAndLikes(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	status, me := request(t, server, "GET", "/2/users/me", "")
+	require.Equal(t, 200, status)
+	myID := me["data"].(map[string]any)["id"].(string)
+	assert.Equal(t, MeUsername, me["data"].(map[string]any)["username"])
+
+	status, created := request(t, server, "POST", "/2/tweets", `{"text":"hello mock"}`)
+	require.Equal(t, 201, status)
+	postID := created["data"].(map[string]any)["id"].(string)
+
+	status, _ = request(t, server, "POST", "/2/users/"+myID+"/likes", `{"tweet_id":"`+postID+`"}`)
+	require.Equal(t, 200, status)
+
+	_, found := request(t, server, "GET", "/2/tweets/search/recent?query=hello%20-goodbye&max_results=10", "")
+	data := found["data"].([]any)
+	require.Len(t, data, 2, "The seeded post mentions hello too")
+	first := data[0].(map[string]any)
+	assert.Equal(t, postID, first["id"])
+	assert.Equal(t, float64(1), first["public_metrics"].(map[string]any)["like_count"])
+	assert.Len(t, found["includes"].(map[string]any)["users"], 2)
+
+	_, page := request(t, server, "GET", "/2/users/"+myID+"/liked_tweets", "")
+	assert.Equal(t, postID, page["data"].([]any)[0].(map[string]any)["id"])
+
+	status, missing := request(t, server, "GET", "/2/tweets/123", "")
+	assert.Equal(t, 200, status, "Missing posts are partial errors, like the real API")
+	assert.Equal(t, "Not Found Error", missing["errors"].([]any)[0].(map[string]any)["title"])
+
+	status, _ = request(t, server, "POST", "/2/tweets", `{}`)
+	assert.Equal(t, 400, status)
+}
+
+func TestPagination(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+	for range 5 {
+		request(t, server, "POST", "/2/tweets", `{"text":"page me"}`)
+	}
+
+	_, first := request(t, server, "GET", "/2/tweets/search/recent?query=page&max_results=3", "")
+	assert.Len(t, first["data"], 3)
+	token := first["meta"].(map[string]any)["next_token"].(string)
+
+	_, second := request(t, server, "GET", "/2/tweets/search/recent?query=page&max_results=3&next_token="+token, "")
+	assert.Len(t, second["data"], 2)
+	assert.NotContains(t, second["meta"], "next_token")
+}
+
+func TestMediaUpload(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	_, initialized := request(t, server, "POST", "/2/media/upload/initialize", `{"total_bytes":4,"media_type":"video/mp4","media_category":"tweet_video"}`)
+	mediaID := initialized["data"].(map[string]any)["id"].(string)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("segment_index", "0")
+	part, _ := writer.CreateFormFile("media", "clip.mp4")
+	part.Write([]byte("data"))
+	writer.Close()
+	resp, err := http.Post(server.URL+"/2/media/upload/"+mediaID+"/append", writer.FormDataContentType(), body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+
+	_, finalized := request(t, server, "POST", "/2/media/upload/"+mediaID+"/finalize", "")
+	info := finalized["data"].(map[string]any)["processing_info"].(map[string]any)
+	assert.Equal(t, "pending", info["state"])
+
+	var states []string
+	for range 3 {
+		_, status := request(t, server, "GET", "/2/media/upload?command=STATUS&media_id="+mediaID, "")
+		states = append(states, status["data"].(map[string]any)["processing_info"].(map[string]any)["state"].(string))
+	}
+	assert.Equal(t, []string{"in_progress", "succeeded", "succeeded"}, states)
+
+	status, _ := request(t, server, "POST", "/2/tweets", `{"text":"video","media":{"media_ids":["`+mediaID+`"]}}`)
+	assert.Equal(t, 201, status)
+}
+
+func TestFilteredStream(t *testing.T) {
+	mockServer := NewServer()
+	mockServer.Heartbeat = 50 * time.Millisecond
+	server := httptest.NewServer(mockServer)
+	defer server.Close()
+
+	status, added := request(t, server, "POST", "/2/tweets/search/stream/rules", `{"add":[{"value":"cats","tag":"pets"}]}`)
+	require.Equal(t, 201, status)
+	assert.Equal(t, float64(1), added["meta"].(map[string]any)["summary"].(map[string]any)["created"])
+
+	resp, err := http.Get(server.URL + "/2/tweets/search/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The first heartbeat shows the stream is registered
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "\r\n", line, "Heartbeats are blank lines")
+
+	request(t, server, "POST", "/2/tweets", `{"text":"dogs only"}`)
+	request(t, server, "POST", "/2/tweets", `{"text":"I love cats"}`)
+
+	for strings.TrimSpace(line) == "" {
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+	}
+	var event map[string]any
+	require.NoError(t, json.Unmarshal([]byte(line), &event))
+	assert.Equal(t, "I love cats", event["data"].(map[string]any)["text"])
+	assert.Equal(t, "pets", event["matching_rules"].([]any)[0].(map[string]any)["tag"])
+
+	status, deleted := request(t, server, "POST", "/2/tweets/search/stream/rules", `{"delete":{"values":["cats"]}}`)
+	require.Equal(t, 200, status)
+	assert.Equal(t, float64(1), deleted["meta"].(map[string]any)["summary"].(map[string]any)["deleted"])
+}
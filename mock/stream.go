@@ -0,0 +1,179 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// handleStream is the filtered stream: every new post matching a rule is
+// sent as one line, with a blank keep-alive line every Heartbeat
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, http.StatusInternalServerError, "Internal Error", "Streaming is not supported", "about:blank")
+		return
+	}
+
+	events := make(chan []byte, 64)
+	s.mu.Lock()
+	s.streams[events] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, events)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(s.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			w.Write(append(event, '\r', '\n'))
+		case <-heartbeat.C:
+			w.Write([]byte("\r\n"))
+		}
+		flusher.Flush()
+	}
+}
+
+// publish sends a new post to connected streams when it matches a rule.
+// Callers hold s.mu or own s.
+func (s *Server) publish(p *post) {
+	if len(s.streams) == 0 {
+		return
+	}
+
+	author := s.users[p.AuthorID]
+	var matching []map[string]string
+	for _, rule := range s.rules {
+		if matchQuery(rule.Value, p.Text, author.Username) {
+			matching = append(matching, map[string]string{"id": rule.ID, "tag": rule.Tag})
+		}
+	}
+	if len(matching) == 0 {
+		return
+	}
+
+	event, _ := json.Marshal(map[string]any{
+		"data": map[string]any{
+			"id":                     p.ID,
+			"text":                   p.Text,
+			"edit_history_tweet_ids": p.EditHistoryTweets,
+		},
+		"matching_rules": matching,
+	})
+	for stream := range s.streams {
+		select {
+		case stream <- event:
+		default:
+			// A slow reader misses events rather than blocking posts
+		}
+	}
+}
+
+func (s *Server) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta := map[string]any{"sent": now(), "result_count": len(s.rules)}
+	if len(s.rules) == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"meta": meta})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.rules, "meta": meta})
+}
+
+// handleUpdateRules adds or deletes rules. With dry_run=true the changes are
+// validated and reported but not applied.
+func (s *Server) handleUpdateRules(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Add []struct {
+			Value string `json:"value"`
+			Tag   string `json:"tag"`
+		} `json:"add"`
+		Delete *struct {
+			IDs    []string `json:"ids"`
+			Values []string `json:"values"`
+		} `json:"delete"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if (len(body.Add) == 0) == (body.Delete == nil) {
+		writeInvalidRequest(w, "Exactly one of add or delete must be given")
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if body.Delete != nil {
+		deleted := 0
+		kept := s.rules[:0:0]
+		for _, rule := range s.rules {
+			if slices.Contains(body.Delete.IDs, rule.ID) || slices.Contains(body.Delete.Values, rule.Value) {
+				deleted++
+				continue
+			}
+			kept = append(kept, rule)
+		}
+		if !dryRun {
+			s.rules = kept
+		}
+		requested := len(body.Delete.IDs) + len(body.Delete.Values)
+		writeJSON(w, http.StatusOK, map[string]any{"meta": map[string]any{
+			"sent":    now(),
+			"summary": map[string]int{"deleted": deleted, "not_deleted": max(0, requested-deleted)},
+		}})
+		return
+	}
+
+	var created []*rule
+	var errors []map[string]any
+	for _, add := range body.Add {
+		if add.Value == "" {
+			errors = append(errors, map[string]any{"value": add.Value, "title": "Invalid Rule", "detail": "Rules must not be empty", "type": "https://api.twitter.com/2/problems/invalid-rules"})
+			continue
+		}
+		if i := slices.IndexFunc(s.rules, func(existing *rule) bool { return existing.Value == add.Value }); i != -1 {
+			errors = append(errors, map[string]any{"value": add.Value, "id": s.rules[i].ID, "title": "DuplicateRule", "type": "https://api.twitter.com/2/problems/duplicate-rules"})
+			continue
+		}
+		created = append(created, &rule{ID: s.id(), Value: add.Value, Tag: add.Tag})
+	}
+	if !dryRun {
+		s.rules = append(s.rules, created...)
+	}
+
+	response := map[string]any{"meta": map[string]any{
+		"sent": now(),
+		"summary": map[string]int{
+			"created":     len(created),
+			"not_created": len(errors),
+			"valid":       len(created),
+			"invalid":     len(errors),
+		},
+	}}
+	if len(created) > 0 {
+		response["data"] = created
+	}
+	if len(errors) > 0 {
+		response["errors"] = errors
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// now formats the current time the way the API does
+func now() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
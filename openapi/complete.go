@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"slices"
+	"strings"
+)
+
+// Complete returns completions for a partly typed request URL. Paths are
+// completed one segment at a time, with template segments such as {id}
+// matching anything already typed. After a "?" it completes query parameter
+// names of the matching operation, and after "name=" the values of
+// parameters with a fixed set, e.g. tweet.fields=created_at,author_id.
+func (s *Spec) Complete(toComplete string) []string {
+	if path, query, ok := strings.Cut(toComplete, "?"); ok {
+		return s.completeQuery(path, query)
+	}
+	return s.completePath(toComplete)
+}
+
+func (s *Spec) completePath(toComplete string) []string {
+	typed := strings.Split(toComplete, "/")
+	last := len(typed) - 1
+
+	var literal, templated []string
+	for _, path := range s.Paths() {
+		segments := strings.Split(path, "/")
+		if len(segments) <= last || !matchSegments(segments[:last], typed[:last]) {
+			continue
+		}
+
+		segment := segments[last]
+		continues := len(segments) > last+1
+		if isTemplate(segment) {
+			if typed[last] == "" {
+				templated = append(templated, completion(typed, segment, continues))
+			} else if continues {
+				// Whatever was typed fills the template, so offer what follows it
+				templated = append(templated, completion(typed, typed[last], true))
+			}
+		} else if strings.HasPrefix(segment, typed[last]) {
+			literal = append(literal, completion(typed, segment, continues))
+		}
+	}
+
+	// A literal match such as /2/users/me hides /2/users/{id}
+	completions := literal
+	if len(literal) == 0 || typed[last] == "" {
+		completions = append(completions, templated...)
+	}
+	slices.Sort(completions)
+	return slices.Compact(completions)
+}
+
+// completion joins the typed segments with the next one, with a trailing
+// slash when the path goes on
+func completion(typed []string, segment string, continues bool) string {
+	joined := strings.Join(append(slices.Clone(typed[:len(typed)-1]), segment), "/")
+	if continues {
+		joined += "/"
+	}
+	return joined
+}
+
+func matchSegments(segments, typed []string) bool {
+	for i, segment := range segments {
+		if !isTemplate(segment) && segment != typed[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Spec) completeQuery(path, query string) []string {
+	operations := s.Find(path)
+	if len(operations) == 0 {
+		return nil
+	}
+	operation := operations[0]
+
+	done, current := "", query
+	if i := strings.LastIndex(query, "&"); i >= 0 {
+		done, current = query[:i+1], query[i+1:]
+	}
+	prefix := path + "?" + done
+
+	name, value, hasValue := strings.Cut(current, "=")
+	if !hasValue {
+		var completions []string
+		for _, param := range operation.Parameters {
+			if param.In == "query" && strings.HasPrefix(param.Name, name) && !strings.Contains(done, param.Name+"=") {
+				completions = append(completions, prefix+param.Name+"=")
+			}
+		}
+		return completions
+	}
+
+	param := operation.Param(name)
+	if param == nil || len(param.Enum) == 0 {
+		return nil
+	}
+	chosen := []string{}
+	partial := value
+	if param.Array {
+		if i := strings.LastIndex(value, ","); i >= 0 {
+			chosen = strings.Split(value[:i], ",")
+			partial = value[i+1:]
+		}
+	}
+	base := strings.TrimSuffix(current, partial)
+
+	var completions []string
+	for _, option := range param.Enum {
+		if strings.HasPrefix(option, partial) && !slices.Contains(chosen, option) {
+			completions = append(completions, prefix+base+option)
+		}
+	}
+	return completions
+}
@@ -0,0 +1,359 @@
+// Package openapi reads the X API v2 OpenAPI document and checks requests
+// against it before they are sent.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed x-api-v2.json
+var bundled []byte
+
+// Auth types, as accepted by --auth
+const (
+	AuthApp    = "app"
+	AuthOAuth1 = "oauth1"
+	AuthOAuth2 = "oauth2"
+)
+
+// securitySchemes maps the spec's security scheme names to auth types
+var securitySchemes = map[string]string{
+	"BearerToken":     AuthApp,
+	"OAuth2UserToken": AuthOAuth2,
+	"UserToken":       AuthOAuth1,
+}
+
+// methodOrder is the order operations on the same path are listed in
+var methodOrder = map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4}
+
+// Spec is a parsed OpenAPI document
+type Spec struct {
+	// Source is where the document came from: "bundled" or a file path
+	Source     string
+	Operations []*Operation
+}
+
+// Operation is one method on one path
+type Operation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tags        []string
+	Parameters  []Parameter
+	// AuthTypes lists the auth types the operation accepts: app, oauth1 and/or oauth2
+	AuthTypes []string
+	// Scopes lists the OAuth 2.0 scopes the operation requires
+	Scopes []string
+
+	segments []string
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Name     string
+	In       string
+	Required bool
+	Type     string
+	// Array is set for comma separated lists such as tweet.fields
+	Array   bool
+	Enum    []string
+	Minimum *float64
+	Maximum *float64
+}
+
+// document is the part of an OpenAPI 3 document xurl reads
+type document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters map[string]rawParameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type rawOperation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags"`
+	Parameters  []rawParameter        `json:"parameters"`
+	Security    []map[string][]string `json:"security"`
+}
+
+type rawParameter struct {
+	Ref      string    `json:"$ref"`
+	Name     string    `json:"name"`
+	In       string    `json:"in"`
+	Required bool      `json:"required"`
+	Schema   rawSchema `json:"schema"`
+}
+
+type rawSchema struct {
+	Type    string     `json:"type"`
+	Enum    []any      `json:"enum"`
+	Minimum *float64   `json:"minimum"`
+	Maximum *float64   `json:"maximum"`
+	Items   *rawSchema `json:"items"`
+}
+
+var (
+	bundledOnce sync.Once
+	bundledSpec *Spec
+	bundledErr  error
+)
+
+// Bundled returns the snapshot of the spec built into xurl
+func Bundled() (*Spec, error) {
+	bundledOnce.Do(func() {
+		bundledSpec, bundledErr = Parse(bundled)
+		if bundledSpec != nil {
+			bundledSpec.Source = "bundled"
+		}
+	})
+	return bundledSpec, bundledErr
+}
+
+// StoredPath returns where a refreshed spec is kept
+func StoredPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".xurl-openapi.json")
+}
+
+// Load returns the refreshed spec saved with Install if there is one, and
+// the bundled snapshot otherwise
+func Load() (*Spec, error) {
+	path := StoredPath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Bundled()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	spec.Source = path
+	return spec, nil
+}
+
+// Install checks that data is a usable spec and saves it in place of the
+// bundled snapshot
+func Install(data []byte) (*Spec, error) {
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	path := StoredPath()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("error saving %s: %v", path, err)
+	}
+	spec.Source = path
+	return spec, nil
+}
+
+// Reset removes a spec saved with Install, going back to the bundled snapshot
+func Reset() error {
+	if err := os.Remove(StoredPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Parse reads an OpenAPI 3 document in JSON form
+func Parse(data []byte) (*Spec, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("invalid OpenAPI document: expected version 3.x, got %q", doc.OpenAPI)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("invalid OpenAPI document: no paths")
+	}
+
+	spec := &Spec{}
+	for path, methods := range doc.Paths {
+		for method, raw := range methods {
+			method = strings.ToUpper(method)
+			if _, ok := methodOrder[method]; !ok {
+				// Path level parameters, servers and the like
+				continue
+			}
+			var op rawOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("invalid operation %s %s: %v", method, path, err)
+			}
+			operation, err := doc.operation(method, path, op)
+			if err != nil {
+				return nil, err
+			}
+			spec.Operations = append(spec.Operations, operation)
+		}
+	}
+
+	sort.Slice(spec.Operations, func(i, j int) bool {
+		a, b := spec.Operations[i], spec.Operations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return methodOrder[a.Method] < methodOrder[b.Method]
+	})
+	return spec, nil
+}
+
+// operation converts a raw operation, resolving parameter references
+func (d *document) operation(method, path string, op rawOperation) (*Operation, error) {
+	operation := &Operation{
+		Method:      method,
+		Path:        path,
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Tags:        op.Tags,
+		segments:    strings.Split(strings.Trim(path, "/"), "/"),
+	}
+
+	for _, raw := range op.Parameters {
+		if raw.Ref != "" {
+			name := strings.TrimPrefix(raw.Ref, "#/components/parameters/")
+			resolved, ok := d.Components.Parameters[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid operation %s %s: unknown parameter %s", method, path, raw.Ref)
+			}
+			raw = resolved
+		}
+		operation.Parameters = append(operation.Parameters, raw.parameter())
+	}
+
+	seen := make(map[string]bool)
+	for _, requirement := range op.Security {
+		for scheme, scopes := range requirement {
+			authType, ok := securitySchemes[scheme]
+			if !ok || seen[authType] {
+				continue
+			}
+			seen[authType] = true
+			operation.AuthTypes = append(operation.AuthTypes, authType)
+			if authType == AuthOAuth2 {
+				operation.Scopes = scopes
+			}
+		}
+	}
+	sort.Strings(operation.AuthTypes)
+	return operation, nil
+}
+
+func (p rawParameter) parameter() Parameter {
+	schema := p.Schema
+	param := Parameter{
+		Name:     p.Name,
+		In:       p.In,
+		Required: p.Required,
+		Type:     schema.Type,
+		Minimum:  schema.Minimum,
+		Maximum:  schema.Maximum,
+	}
+	if schema.Type == "array" && schema.Items != nil {
+		param.Array = true
+		schema = *schema.Items
+	}
+	for _, value := range schema.Enum {
+		param.Enum = append(param.Enum, fmt.Sprint(value))
+	}
+	return param
+}
+
+// Param returns the named parameter of an operation
+func (o *Operation) Param(name string) *Parameter {
+	for i := range o.Parameters {
+		if o.Parameters[i].Name == name {
+			return &o.Parameters[i]
+		}
+	}
+	return nil
+}
+
+// Match reports how well a path matches the operation: -1 when it doesn't,
+// otherwise the number of literal segments that matched, so that
+// /2/users/me is preferred over /2/users/{id}
+func (o *Operation) Match(path string) int {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(o.segments) {
+		return -1
+	}
+	literal := 0
+	for i, segment := range o.segments {
+		if isTemplate(segment) {
+			if segments[i] == "" {
+				return -1
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return -1
+		}
+		literal++
+	}
+	return literal
+}
+
+// Find returns the operations on the path that best matches path, one per method
+func (s *Spec) Find(path string) []*Operation {
+	best := -1
+	var found []*Operation
+	for _, op := range s.Operations {
+		score := op.Match(path)
+		switch {
+		case score < 0 || score < best:
+			continue
+		case score > best:
+			best = score
+			found = nil
+		}
+		found = append(found, op)
+	}
+	return found
+}
+
+// Filter returns the operations whose path, method, operation ID, summary or
+// tags contain text, ignoring case
+func (s *Spec) Filter(text string) []*Operation {
+	text = strings.ToLower(text)
+	var found []*Operation
+	for _, op := range s.Operations {
+		fields := append([]string{op.Method, op.Path, op.OperationID, op.Summary}, op.Tags...)
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field), text) {
+				found = append(found, op)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Paths returns every distinct path in the spec, in order
+func (s *Spec) Paths() []string {
+	var paths []string
+	for _, op := range s.Operations {
+		if len(paths) == 0 || paths[len(paths)-1] != op.Path {
+			paths = append(paths, op.Path)
+		}
+	}
+	return paths
+}
+
+func isTemplate(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
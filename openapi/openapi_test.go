@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+func TestBundledSpec(t *testing.T) {
+	spec, err := Bundled()
+	require.NoError(t, err)
+	assert.Equal(t, "bundled", spec.Source)
+	assert.NotEmpty(t, spec.Operations)
+
+	me := spec.Find("/2/users/me")
+	require.Len(t, me, 1)
+	assert.Equal(t, "findMyUser", me[0].OperationID)
+	assert.Equal(t, []string{AuthOAuth1, AuthOAuth2}, me[0].AuthTypes)
+	assert.Contains(t, me[0].Scopes, "users.read")
+
+	fields := me[0].Param("tweet.fields")
+	require.NotNil(t, fields, "Parameter references are resolved")
+	assert.True(t, fields.Array)
+	assert.Contains(t, fields.Enum, "created_at")
+
+	byID := spec.Find("/2/users/123")
+	require.Len(t, byID, 1)
+	assert.Equal(t, "/2/users/{id}", byID[0].Path)
+
+	tweets := spec.Find("/2/tweets/123")
+	require.Len(t, tweets, 2)
+	assert.Equal(t, "GET", tweets[0].Method)
+	assert.Equal(t, "DELETE", tweets[1].Method)
+
+	stream := spec.Find("/2/tweets/search/stream")
+	require.Len(t, stream, 1)
+	assert.Equal(t, []string{AuthApp}, stream[0].AuthTypes)
+}
+
+func TestValidate(t *testing.T) {
+	spec, err := Bundled()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		warning string
+		message string
+	}{
+		{"known path", "GET", "/2/users/me", "", ""},
+		{"template path", "GET", "/2/users/by/username/XDevelopers?user.fields=created_at,description", "", ""},
+		{"full URL", "GET", "https://api.x.com/2/tweets/search/recent?query=golang&max_results=10", "", ""},
+		{"not a v2 path", "GET", "/1.1/statuses/show.json", "", ""},
+		{"unknown path", "GET", "/2/user/me", "/2/user/me is not in the OpenAPI spec (did you mean /2/users/me?)", ""},
+		{"stream not in the spec", "GET", "/2/tweets/compliance/stream?partition=1", "/2/tweets/compliance/stream is not in the OpenAPI spec", ""},
+		{"unknown field", "GET", "/2/tweets/123?tweet.fields=text,creatd_at", `unknown tweet.fields value "creatd_at" (did you mean "created_at"?)`, ""},
+		{"unknown expansion", "GET", "/2/users/me?expansions=pinned_tweet", `unknown expansions value "pinned_tweet" (did you mean "pinned_tweet_id"?)`, ""},
+		{"no suggestion", "GET", "/2/users/me?user.fields=zzzzzzzz", `unknown user.fields value "zzzzzzzz"`, ""},
+		{"out of range", "GET", "/2/tweets/search/recent?query=go&max_results=5", "max_results must be between 10 and 100, got 5", ""},
+		{"wrong method", "PUT", "/2/tweets/123", "", "PUT /2/tweets/{id} is not supported (use GET, DELETE)"},
+		{"missing query", "GET", "/2/tweets/search/recent", "", "missing required query parameter query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := spec.Validate(tt.method, tt.url)
+			if tt.warning == "" {
+				assert.Empty(t, warnings)
+			} else {
+				require.Len(t, warnings, 1)
+				assert.Contains(t, warnings[0], tt.warning)
+			}
+			if tt.message == "" {
+				assert.NoError(t, err, "Only conflicts with the spec stop a request")
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, xurlErrors.IsValidationError(err))
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	spec, err := Bundled()
+	require.NoError(t, err)
+
+	found := spec.Filter("BOOKMARK")
+	require.NotEmpty(t, found)
+	for _, op := range found {
+		assert.Contains(t, op.Path, "bookmarks")
+	}
+	assert.Empty(t, spec.Filter("no such endpoint"))
+}
+
+func TestInstall(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	spec, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "bundled", spec.Source)
+
+	_, err = Install([]byte(`{"openapi":"2.0"}`))
+	assert.Error(t, err)
+
+	custom := `{"openapi":"3.0.0","paths":{"/2/custom":{"get":{"operationId":"custom","security":[{"BearerToken":[]}]}}}}`
+	_, err = Install([]byte(custom))
+	require.NoError(t, err)
+
+	spec, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".xurl-openapi.json"), spec.Source)
+	require.Len(t, spec.Operations, 1)
+	assert.Equal(t, "/2/custom", spec.Operations[0].Path)
+
+	require.NoError(t, Reset())
+	_, err = os.Stat(StoredPath())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestComplete(t *testing.T) {
+	spec, err := Bundled()
+	require.NoError(t, err)
+
+	tests := []struct {
+		typed    string
+		contains []string
+		excludes []string
+	}{
+		{"/2/us", []string{"/2/users/", "/2/usage/"}, nil},
+		{"/2/users/m", []string{"/2/users/me"}, []string{"/2/users/m/"}},
+		{"/2/users/", []string{"/2/users/me", "/2/users/{id}", "/2/users/{id}/"}, nil},
+		{"/2/users/123/f", []string{"/2/users/123/followers", "/2/users/123/following/"}, []string{"/2/users/123/tweets"}},
+		{"/2/users/me?exp", []string{"/2/users/me?expansions="}, nil},
+		{"/2/users/me?user.fields=id,user", []string{"/2/users/me?user.fields=id,username"}, nil},
+		{"/2/users/me?user.fields=id,", []string{"/2/users/me?user.fields=id,name"}, []string{"/2/users/me?user.fields=id,id"}},
+		{"/2/tweets/search/recent?query=go&tweet.fields=created", []string{"/2/tweets/search/recent?query=go&tweet.fields=created_at"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typed, func(t *testing.T) {
+			completions := spec.Complete(tt.typed)
+			for _, c := range tt.contains {
+				assert.Contains(t, completions, c)
+			}
+			for _, c := range tt.excludes {
+				assert.NotContains(t, completions, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+// Validate checks a request against the spec. Only clear conflicts with an
+// operation in the spec are errors: a method the path doesn't take or a
+// missing required query parameter. A path the spec doesn't have, unknown
+// values for fields such as tweet.fields and expansions and out-of-range
+// numbers are returned as warnings, since the spec can lag behind the API.
+// Only /2/ paths are checked; anything else is left to the server.
+func (s *Spec) Validate(method, endpoint string) ([]string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || !strings.HasPrefix(u.Path, "/2/") {
+		return nil, nil
+	}
+	method = strings.ToUpper(method)
+	path := strings.TrimSuffix(u.Path, "/")
+
+	operations := s.Find(path)
+	if len(operations) == 0 {
+		warning := fmt.Sprintf("%s is not in the OpenAPI spec", path)
+		if suggestion := s.suggestPath(path); suggestion != "" {
+			warning += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+		return []string{warning}, nil
+	}
+
+	var operation *Operation
+	var methods []string
+	for _, op := range operations {
+		methods = append(methods, op.Method)
+		if op.Method == method {
+			operation = op
+		}
+	}
+	if operation == nil {
+		return nil, xurlErrors.NewValidationError(fmt.Sprintf("%s %s is not supported (use %s)", method, operations[0].Path, strings.Join(methods, ", ")))
+	}
+
+	var problems, warnings []string
+	query := u.Query()
+	for _, param := range operation.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		values, present := query[param.Name]
+		if !present {
+			if param.Required {
+				problems = append(problems, fmt.Sprintf("missing required query parameter %s", param.Name))
+			}
+			continue
+		}
+		for _, value := range values {
+			for _, warning := range param.check(value) {
+				warnings = append(warnings, fmt.Sprintf("%s %s: %s", method, operation.Path, warning))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return warnings, xurlErrors.NewValidationError(fmt.Sprintf("%s %s: %s", method, operation.Path, strings.Join(problems, "; ")))
+	}
+	return warnings, nil
+}
+
+// check returns what the spec doesn't expect about a query parameter value
+func (p *Parameter) check(value string) []string {
+	var problems []string
+	items := []string{value}
+	if p.Array {
+		items = strings.Split(value, ",")
+	}
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, item) {
+			problem := fmt.Sprintf("unknown %s value %q", p.Name, item)
+			if suggestion := closest(item, p.Enum); suggestion != "" {
+				problem += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			problems = append(problems, problem)
+			continue
+		}
+		if p.Type == "integer" {
+			problems = append(problems, p.checkRange(item)...)
+		}
+	}
+	return problems
+}
+
+func (p *Parameter) checkRange(value string) []string {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return []string{fmt.Sprintf("%s must be a number, got %q", p.Name, value)}
+	}
+	if (p.Minimum != nil && n < *p.Minimum) || (p.Maximum != nil && n > *p.Maximum) {
+		return []string{fmt.Sprintf("%s must be between %s and %s, got %s", p.Name, bound(p.Minimum), bound(p.Maximum), value)}
+	}
+	return nil
+}
+
+func bound(b *float64) string {
+	if b == nil {
+		return "any"
+	}
+	return strconv.FormatFloat(*b, 'f', -1, 64)
+}
+
+// suggestPath returns the spec path closest to path, with its template
+// segments filled in from path where they line up
+func (s *Spec) suggestPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestDistance := "", -1
+	for _, candidate := range s.Paths() {
+		filled := strings.Split(strings.Trim(candidate, "/"), "/")
+		for i, segment := range filled {
+			if isTemplate(segment) && i < len(segments) {
+				filled[i] = segments[i]
+			}
+		}
+		distance := levenshtein(path, "/"+strings.Join(filled, "/"))
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if bestDistance < 0 || bestDistance > max(3, len(path)/4) {
+		return ""
+	}
+	return best
+}
+
+// closest returns the value nearest to s, or "" if none is close enough to
+// be a likely typo
+func closest(s string, values []string) string {
+	best, bestDistance := "", -1
+	for _, value := range values {
+		distance := levenshtein(s, value)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = value, distance
+		}
+	}
+	if bestDistance < 0 || bestDistance > max(2, len(s)/3) {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
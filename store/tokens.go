@@ -8,6 +8,7 @@ import (
 	"sort"
 
 	"github.com/xdevplatform/xurl/errors"
+	"github.com/xdevplatform/xurl/transport"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +17,8 @@ import (
 
 // Represents OAuth1 authentication tokens
 type OAuth1Token struct {
+	// Username is the screen name of the user the tokens act as, when known
+	Username       string `yaml:"username,omitempty" json:"username,omitempty"`
 	AccessToken    string `yaml:"access_token" json:"access_token"`
 	TokenSecret    string `yaml:"token_secret" json:"token_secret"`
 	ConsumerKey    string `yaml:"consumer_key" json:"consumer_key"`
@@ -50,12 +53,24 @@ type Token struct {
 
 // App holds the credentials and tokens for a single registered X API application.
 type App struct {
-	ClientID     string           `yaml:"client_id"`
-	ClientSecret string           `yaml:"client_secret"`
-	DefaultUser  string           `yaml:"default_user,omitempty"`
-	OAuth2Tokens map[string]Token `yaml:"oauth2_tokens,omitempty"`
-	OAuth1Token  *Token           `yaml:"oauth1_token,omitempty"`
-	BearerToken  *Token           `yaml:"bearer_token,omitempty"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// ConsumerKey and ConsumerSecret are the app's API key and secret, which
+	// bearer tokens are generated with
+	ConsumerKey       string           `yaml:"consumer_key,omitempty"`
+	ConsumerSecret    string           `yaml:"consumer_secret,omitempty"`
+	DefaultUser       string           `yaml:"default_user,omitempty"`
+	OAuth2Tokens      map[string]Token `yaml:"oauth2_tokens,omitempty"`
+	DefaultOAuth1User string           `yaml:"default_oauth1_user,omitempty"`
+	// OAuth1Tokens are keyed by screen name; tokens saved without one are
+	// kept under ""
+	OAuth1Tokens map[string]Token `yaml:"oauth1_tokens,omitempty"`
+	// OAuth1Token is the single OAuth1 token of older files, moved into
+	// OAuth1Tokens when the store is loaded
+	OAuth1Token *Token `yaml:"oauth1_token,omitempty"`
+	BearerToken *Token `yaml:"bearer_token,omitempty"`
+	// Transport holds timeouts, proxy and TLS settings used for this app's requests
+	Transport *transport.Options `yaml:"transport,omitempty"`
 }
 
 // ─── On-disk YAML structure ─────────────────────────────────────────
@@ -116,7 +131,7 @@ func NewTokenStoreWithCredentials(clientID, clientSecret string) *TokenStore {
 	if clientID != "" || clientSecret != "" {
 		dirty := false
 		for _, app := range store.Apps {
-			hasTokens := len(app.OAuth2Tokens) > 0 || app.OAuth1Token != nil || app.BearerToken != nil
+			hasTokens := len(app.OAuth2Tokens) > 0 || len(app.OAuth1Tokens) > 0 || app.BearerToken != nil
 			if hasTokens && app.ClientID == "" && clientID != "" {
 				app.ClientID = clientID
 				dirty = true
@@ -133,7 +148,7 @@ func NewTokenStoreWithCredentials(clientID, clientSecret string) *TokenStore {
 
 	// Import from .twurlrc if we have no apps or the default app is missing OAuth1/Bearer
 	app := store.activeApp()
-	if app == nil || app.OAuth1Token == nil || app.BearerToken == nil {
+	if app == nil || len(app.OAuth1Tokens) == 0 || app.BearerToken == nil {
 		twurlPath := filepath.Join(homeDir, ".twurlrc")
 		if _, err := os.Stat(twurlPath); err == nil {
 			if err := store.importFromTwurlrc(twurlPath); err != nil {
@@ -153,10 +168,17 @@ func (s *TokenStore) loadFromData(data []byte) {
 		s.Apps = sf.Apps
 		s.DefaultApp = sf.DefaultApp
 		// Ensure all apps have initialised maps
+		migrated := false
 		for _, app := range s.Apps {
 			if app.OAuth2Tokens == nil {
 				app.OAuth2Tokens = make(map[string]Token)
 			}
+			if app.migrateOAuth1Token() {
+				migrated = true
+			}
+		}
+		if migrated {
+			_ = s.saveToFile()
 		}
 		return
 	}
@@ -173,12 +195,40 @@ func (s *TokenStore) loadFromData(data []byte) {
 			OAuth1Token:  legacy.OAuth1Token,
 			BearerToken:  legacy.BearerToken,
 		}
+		s.Apps["default"].migrateOAuth1Token()
 		s.DefaultApp = "default"
 		// Persist in new YAML format immediately
 		_ = s.saveToFile()
 	}
 }
 
+// migrateOAuth1Token moves the single OAuth1 token of older files into
+// OAuth1Tokens, as the default account. It reports whether there was one.
+func (app *App) migrateOAuth1Token() bool {
+	if app.OAuth1Token == nil {
+		return false
+	}
+	if old := app.OAuth1Token.OAuth1; old != nil {
+		if _, ok := app.OAuth1Tokens[old.Username]; !ok {
+			app.setOAuth1Token(old.Username, *app.OAuth1Token)
+		}
+	}
+	app.OAuth1Token = nil
+	return true
+}
+
+// setOAuth1Token saves an OAuth1 account, which becomes the default when
+// there is none yet
+func (app *App) setOAuth1Token(username string, token Token) {
+	if app.OAuth1Tokens == nil {
+		app.OAuth1Tokens = make(map[string]Token)
+	}
+	if _, ok := app.OAuth1Tokens[app.DefaultOAuth1User]; !ok {
+		app.DefaultOAuth1User = username
+	}
+	app.OAuth1Tokens[username] = token
+}
+
 // ─── App management ─────────────────────────────────────────────────
 
 // AddApp registers a new application. If it's the only app it becomes default.
@@ -212,6 +262,47 @@ func (s *TokenStore) UpdateApp(name, clientID, clientSecret string) error {
 	return s.saveToFile()
 }
 
+// SetAppTransport stores the transport settings for an existing application.
+// Options that are set replace the stored ones; reset clears them first.
+func (s *TokenStore) SetAppTransport(name string, options transport.Options, reset bool) error {
+	app, exists := s.Apps[name]
+	if !exists {
+		return errors.NewTokenStoreError(fmt.Sprintf("app %q not found", name))
+	}
+	merged := options
+	if app.Transport != nil && !reset {
+		merged = app.Transport.Merge(options)
+	}
+	if merged.IsZero() {
+		app.Transport = nil
+	} else {
+		app.Transport = &merged
+	}
+	return s.saveToFile()
+}
+
+// SetConsumerCredentialsForApp saves the API key and secret of the named (or default) app.
+func (s *TokenStore) SetConsumerCredentialsForApp(appName, consumerKey, consumerSecret string) error {
+	app := s.ResolveApp(appName)
+	app.ConsumerKey = consumerKey
+	app.ConsumerSecret = consumerSecret
+	return s.saveToFile()
+}
+
+// GetConsumerCredentialsForApp returns the API key and secret of the named
+// (or default) app: the saved ones, or else those of its default OAuth1
+// account. Both are empty when the app has neither.
+func (s *TokenStore) GetConsumerCredentialsForApp(appName string) (consumerKey, consumerSecret string) {
+	app := s.ResolveApp(appName)
+	if app.ConsumerKey != "" && app.ConsumerSecret != "" {
+		return app.ConsumerKey, app.ConsumerSecret
+	}
+	if token := s.GetOAuth1TokensForApp(appName); token != nil && token.OAuth1 != nil {
+		return token.OAuth1.ConsumerKey, token.OAuth1.ConsumerSecret
+	}
+	return "", ""
+}
+
 // RemoveApp removes a registered application and its tokens.
 func (s *TokenStore) RemoveApp(name string) error {
 	if _, exists := s.Apps[name]; !exists {
@@ -269,6 +360,16 @@ func (s *TokenStore) GetDefaultUser(appName string) string {
 	return app.DefaultUser
 }
 
+// SetDefaultOAuth1User sets the default OAuth1 account for the named (or default) app.
+func (s *TokenStore) SetDefaultOAuth1User(appName, username string) error {
+	app := s.ResolveApp(appName)
+	if _, ok := app.OAuth1Tokens[username]; !ok {
+		return errors.NewTokenStoreError(fmt.Sprintf("OAuth1 user %q not found in app", username))
+	}
+	app.DefaultOAuth1User = username
+	return s.saveToFile()
+}
+
 // GetDefaultApp returns the default app name.
 func (s *TokenStore) GetDefaultApp() string {
 	return s.DefaultApp
@@ -342,23 +443,32 @@ func (s *TokenStore) importFromTwurlrc(filePath string) error {
 
 	app := s.activeAppOrCreate()
 
-	// Import the first OAuth1 tokens from twurlrc
-	for _, consumerKeys := range twurlConfig.Profiles {
-		for consumerKey, profile := range consumerKeys {
-			if app.OAuth1Token == nil {
-				app.OAuth1Token = &Token{
+	// Import an OAuth1 account for each twurl profile, unless the app has
+	// its own. twurl's default profile becomes the default account.
+	if len(app.OAuth1Tokens) == 0 {
+		for username, consumerKeys := range twurlConfig.Profiles {
+			for consumerKey, profile := range consumerKeys {
+				if profile.Username != "" {
+					username = profile.Username
+				}
+				app.setOAuth1Token(username, Token{
 					Type: OAuth1TokenType,
 					OAuth1: &OAuth1Token{
+						Username:       username,
 						AccessToken:    profile.Token,
 						TokenSecret:    profile.Secret,
 						ConsumerKey:    consumerKey,
 						ConsumerSecret: profile.ConsumerSecret,
 					},
-				}
+				})
+				break
+			}
+		}
+		if profile := twurlConfig.Configuration.DefaultProfile; len(profile) > 0 {
+			if _, ok := app.OAuth1Tokens[profile[0]]; ok {
+				app.DefaultOAuth1User = profile[0]
 			}
-			break
 		}
-		break
 	}
 
 	// Import the first bearer token from twurlrc
@@ -416,21 +526,24 @@ func (s *TokenStore) SaveOAuth2TokenForApp(appName, username, accessToken, refre
 
 // SaveOAuth1Tokens saves OAuth1 tokens into the resolved app.
 func (s *TokenStore) SaveOAuth1Tokens(accessToken, tokenSecret, consumerKey, consumerSecret string) error {
-	return s.SaveOAuth1TokensForApp("", accessToken, tokenSecret, consumerKey, consumerSecret)
+	return s.SaveOAuth1TokensForApp("", "", accessToken, tokenSecret, consumerKey, consumerSecret)
 }
 
-// SaveOAuth1TokensForApp saves OAuth1 tokens into the named app.
-func (s *TokenStore) SaveOAuth1TokensForApp(appName, accessToken, tokenSecret, consumerKey, consumerSecret string) error {
+// SaveOAuth1TokensForApp saves OAuth1 tokens into the named app as the
+// account of username, the screen name the tokens belong to, if known. The
+// first account saved becomes the default.
+func (s *TokenStore) SaveOAuth1TokensForApp(appName, username, accessToken, tokenSecret, consumerKey, consumerSecret string) error {
 	app := s.ResolveApp(appName)
-	app.OAuth1Token = &Token{
+	app.setOAuth1Token(username, Token{
 		Type: OAuth1TokenType,
 		OAuth1: &OAuth1Token{
+			Username:       username,
 			AccessToken:    accessToken,
 			TokenSecret:    tokenSecret,
 			ConsumerKey:    consumerKey,
 			ConsumerSecret: consumerSecret,
 		},
-	}
+	})
 	return s.saveToFile()
 }
 
@@ -468,15 +581,35 @@ func (s *TokenStore) GetFirstOAuth2TokenForApp(appName string) *Token {
 	return nil
 }
 
-// GetOAuth1Tokens gets OAuth1 tokens from the resolved app.
+// GetOAuth1Tokens gets the default account's OAuth1 tokens from the resolved app.
 func (s *TokenStore) GetOAuth1Tokens() *Token {
 	return s.GetOAuth1TokensForApp("")
 }
 
-// GetOAuth1TokensForApp gets OAuth1 tokens from the named app.
+// GetOAuth1TokensForApp gets the default account's OAuth1 tokens from the named app.
 func (s *TokenStore) GetOAuth1TokensForApp(appName string) *Token {
+	return s.GetOAuth1TokensForUser(appName, "")
+}
+
+// GetOAuth1TokensForUser gets the OAuth1 tokens of a screen name from the
+// named app. An empty username means the default account, or the first one
+// when no default is set.
+func (s *TokenStore) GetOAuth1TokensForUser(appName, username string) *Token {
 	app := s.ResolveApp(appName)
-	return app.OAuth1Token
+	if username == "" {
+		username = app.DefaultOAuth1User
+		if _, ok := app.OAuth1Tokens[username]; !ok {
+			usernames := s.GetOAuth1UsernamesForApp(appName)
+			if len(usernames) == 0 {
+				return nil
+			}
+			username = usernames[0]
+		}
+	}
+	if token, ok := app.OAuth1Tokens[username]; ok {
+		return &token
+	}
+	return nil
 }
 
 // GetBearerToken gets the bearer token from the resolved app.
@@ -507,10 +640,24 @@ func (s *TokenStore) ClearOAuth1Tokens() error {
 	return s.ClearOAuth1TokensForApp("")
 }
 
-// ClearOAuth1TokensForApp clears OAuth1 tokens from the named app.
+// ClearOAuth1TokensForApp clears every OAuth1 account from the named app.
 func (s *TokenStore) ClearOAuth1TokensForApp(appName string) error {
 	app := s.ResolveApp(appName)
-	app.OAuth1Token = nil
+	app.OAuth1Tokens = nil
+	app.DefaultOAuth1User = ""
+	return s.saveToFile()
+}
+
+// ClearOAuth1TokensForUser clears the OAuth1 account of a screen name from the named app.
+func (s *TokenStore) ClearOAuth1TokensForUser(appName, username string) error {
+	app := s.ResolveApp(appName)
+	if _, ok := app.OAuth1Tokens[username]; !ok {
+		return errors.NewTokenStoreError(fmt.Sprintf("OAuth1 user %q not found in app", username))
+	}
+	delete(app.OAuth1Tokens, username)
+	if app.DefaultOAuth1User == username {
+		app.DefaultOAuth1User = ""
+	}
 	return s.saveToFile()
 }
 
@@ -535,7 +682,8 @@ func (s *TokenStore) ClearAll() error {
 func (s *TokenStore) ClearAllForApp(appName string) error {
 	app := s.ResolveApp(appName)
 	app.OAuth2Tokens = make(map[string]Token)
-	app.OAuth1Token = nil
+	app.OAuth1Tokens = nil
+	app.DefaultOAuth1User = ""
 	app.BearerToken = nil
 	return s.saveToFile()
 }
@@ -556,10 +704,21 @@ func (s *TokenStore) GetOAuth2UsernamesForApp(appName string) []string {
 	return usernames
 }
 
+// GetOAuth1UsernamesForApp gets the screen names of all OAuth1 accounts in the named app.
+func (s *TokenStore) GetOAuth1UsernamesForApp(appName string) []string {
+	app := s.ResolveApp(appName)
+	usernames := make([]string, 0, len(app.OAuth1Tokens))
+	for username := range app.OAuth1Tokens {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
 // HasOAuth1Tokens checks if OAuth1 tokens exist in the resolved app.
 func (s *TokenStore) HasOAuth1Tokens() bool {
 	app := s.activeApp()
-	return app != nil && app.OAuth1Token != nil
+	return app != nil && len(app.OAuth1Tokens) > 0
 }
 
 // HasBearerToken checks if a bearer token exists in the resolved app.
@@ -5,9 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/xdevplatform/xurl/transport"
 )
 
 func createTempTokenStore(t *testing.T) (*TokenStore, string) {
@@ -223,6 +226,87 @@ func TestMultiApp(t *testing.T) {
 	})
 }
 
+func TestOAuth1Accounts(t *testing.T) {
+	store, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	require.NoError(t, store.SaveOAuth1TokensForApp("", "alice", "a-tok", "a-sec", "ck", "cs"))
+	require.NoError(t, store.SaveOAuth1TokensForApp("", "bob", "b-tok", "b-sec", "ck", "cs"))
+
+	assert.Equal(t, []string{"alice", "bob"}, store.GetOAuth1UsernamesForApp(""))
+	assert.Equal(t, "a-tok", store.GetOAuth1Tokens().OAuth1.AccessToken, "The first account is the default")
+	assert.Equal(t, "b-tok", store.GetOAuth1TokensForUser("", "bob").OAuth1.AccessToken)
+	assert.Nil(t, store.GetOAuth1TokensForUser("", "nobody"))
+
+	assert.Error(t, store.SetDefaultOAuth1User("", "nobody"))
+	require.NoError(t, store.SetDefaultOAuth1User("", "bob"))
+	assert.Equal(t, "b-tok", store.GetOAuth1Tokens().OAuth1.AccessToken)
+
+	assert.Error(t, store.ClearOAuth1TokensForUser("", "nobody"))
+	require.NoError(t, store.ClearOAuth1TokensForUser("", "bob"))
+	assert.Equal(t, []string{"alice"}, store.GetOAuth1UsernamesForApp(""))
+	assert.Equal(t, "a-tok", store.GetOAuth1Tokens().OAuth1.AccessToken, "The remaining account is used")
+	assert.True(t, store.HasOAuth1Tokens())
+}
+
+func TestConsumerCredentials(t *testing.T) {
+	store, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	key, secret := store.GetConsumerCredentialsForApp("")
+	assert.Empty(t, key)
+	assert.Empty(t, secret)
+
+	require.NoError(t, store.SaveOAuth1TokensForApp("", "alice", "at", "ts", "oauth1-ck", "oauth1-cs"))
+	key, secret = store.GetConsumerCredentialsForApp("")
+	assert.Equal(t, "oauth1-ck", key, "The OAuth1 account's credentials are used")
+	assert.Equal(t, "oauth1-cs", secret)
+
+	require.NoError(t, store.SetConsumerCredentialsForApp("", "ck", "cs"))
+	key, secret = store.GetConsumerCredentialsForApp("")
+	assert.Equal(t, "ck", key, "The app's own credentials come first")
+	assert.Equal(t, "cs", secret)
+}
+
+func TestOAuth1TokenMigration(t *testing.T) {
+	tempDir := t.TempDir()
+	xurlPath := filepath.Join(tempDir, ".xurl")
+
+	old := `apps:
+  myapp:
+    client_id: cid
+    client_secret: csec
+    oauth1_token:
+      type: oauth1
+      oauth1:
+        access_token: at
+        token_secret: ts
+        consumer_key: ck
+        consumer_secret: cs
+default_app: myapp
+`
+	require.NoError(t, os.WriteFile(xurlPath, []byte(old), 0600))
+
+	s := &TokenStore{Apps: make(map[string]*App), FilePath: xurlPath}
+	s.loadFromData([]byte(old))
+
+	app := s.GetApp("myapp")
+	assert.Nil(t, app.OAuth1Token)
+	token := s.GetOAuth1Tokens()
+	require.NotNil(t, token)
+	assert.Equal(t, "at", token.OAuth1.AccessToken)
+
+	// A new account is added next to the migrated one
+	require.NoError(t, s.SaveOAuth1TokensForApp("myapp", "alice", "a-tok", "a-sec", "ck", "cs"))
+	assert.Equal(t, []string{"", "alice"}, s.GetOAuth1UsernamesForApp("myapp"))
+	assert.Equal(t, "at", s.GetOAuth1Tokens().OAuth1.AccessToken, "The migrated token stays the default")
+
+	raw, err := os.ReadFile(xurlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "oauth1_tokens:")
+	assert.NotContains(t, string(raw), "oauth1_token:")
+}
+
 func TestUpdateApp(t *testing.T) {
 	store, tempDir := createTempTokenStore(t)
 	defer os.RemoveAll(tempDir)
@@ -259,6 +343,37 @@ func TestUpdateApp(t *testing.T) {
 	})
 }
 
+func TestSetAppTransport(t *testing.T) {
+	store, tempDir := createTempTokenStore(t)
+	defer os.RemoveAll(tempDir)
+
+	store.AddApp("myapp", "id", "secret")
+
+	t.Run("Options are merged and persisted", func(t *testing.T) {
+		require.NoError(t, store.SetAppTransport("myapp", transport.Options{Proxy: "socks5://127.0.0.1:1080"}, false))
+		require.NoError(t, store.SetAppTransport("myapp", transport.Options{Timeout: 2 * time.Minute}, false))
+
+		reloaded := &TokenStore{Apps: make(map[string]*App), FilePath: store.FilePath}
+		data, err := os.ReadFile(store.FilePath)
+		require.NoError(t, err)
+		reloaded.loadFromData(data)
+
+		app := reloaded.GetApp("myapp")
+		require.NotNil(t, app.Transport)
+		assert.Equal(t, "socks5://127.0.0.1:1080", app.Transport.Proxy)
+		assert.Equal(t, 2*time.Minute, app.Transport.Timeout)
+	})
+
+	t.Run("Reset clears saved options", func(t *testing.T) {
+		require.NoError(t, store.SetAppTransport("myapp", transport.Options{}, true))
+		assert.Nil(t, store.GetApp("myapp").Transport)
+	})
+
+	t.Run("Nonexistent app fails", func(t *testing.T) {
+		assert.Error(t, store.SetAppTransport("nope", transport.Options{Insecure: true}, false))
+	})
+}
+
 func TestCredentialBackfill(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "xurl-backfill-test")
 	require.NoError(t, err)
@@ -322,11 +437,12 @@ func TestForAppVariants(t *testing.T) {
 	})
 
 	t.Run("SaveOAuth1TokensForApp", func(t *testing.T) {
-		err := store.SaveOAuth1TokensForApp("a2", "at", "ts", "ck", "cs")
+		err := store.SaveOAuth1TokensForApp("a2", "alice", "at", "ts", "ck", "cs")
 		require.NoError(t, err)
 		tok := store.GetOAuth1TokensForApp("a2")
 		require.NotNil(t, tok)
 		assert.Equal(t, "at", tok.OAuth1.AccessToken)
+		assert.Equal(t, "alice", tok.OAuth1.Username)
 		// a1 should not have it
 		assert.Nil(t, store.GetOAuth1TokensForApp("a1"))
 	})
@@ -363,7 +479,7 @@ func TestForAppVariants(t *testing.T) {
 	t.Run("ClearAllForApp", func(t *testing.T) {
 		store.SaveOAuth2TokenForApp("a1", "x", "t", "r", 1)
 		store.SaveBearerTokenForApp("a1", "b")
-		store.SaveOAuth1TokensForApp("a1", "a", "t", "c", "s")
+		store.SaveOAuth1TokensForApp("a1", "", "a", "t", "c", "s")
 
 		err := store.ClearAllForApp("a1")
 		require.NoError(t, err)
@@ -560,9 +676,10 @@ configuration:
 		// Verify the OAuth1 token was imported into the default app
 		app := store.GetApp("default")
 		require.NotNil(t, app, "default app is nil after import")
-		require.NotNil(t, app.OAuth1Token, "OAuth1Token is nil after import")
+		require.Contains(t, app.OAuth1Tokens, "testuser", "OAuth1 account is missing after import")
+		assert.Equal(t, "testuser", app.DefaultOAuth1User)
 
-		oauth1 := app.OAuth1Token.OAuth1
+		oauth1 := app.OAuth1Tokens["testuser"].OAuth1
 		assert.Equal(t, "test_access_token", oauth1.AccessToken, "Unexpected access token")
 		assert.Equal(t, "test_token_secret", oauth1.TokenSecret, "Unexpected token secret")
 		assert.Equal(t, "test_consumer_key", oauth1.ConsumerKey, "Unexpected consumer key")
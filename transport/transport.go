@@ -0,0 +1,175 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	xurlErrors "github.com/xdevplatform/xurl/errors"
+)
+
+const (
+	// DefaultTimeout is the overall request timeout when none is configured
+	DefaultTimeout = 30 * time.Second
+	// DefaultConnectTimeout bounds dialing and the TLS handshake when none is configured
+	DefaultConnectTimeout = 30 * time.Second
+)
+
+// Options configures the HTTP transport shared by every request xurl makes.
+// They can be stored per app in ~/.xurl and overridden by command-line flags.
+type Options struct {
+	// Timeout bounds a whole request, including reading the body. Streams
+	// are not subject to it.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// ConnectTimeout bounds dialing and the TLS handshake
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty"`
+	// Proxy is an http://, https://, socks5:// or socks5h:// proxy URL.
+	// When empty, HTTPS_PROXY/HTTP_PROXY/NO_PROXY from the environment are used.
+	Proxy string `yaml:"proxy,omitempty"`
+	// CACert is a PEM file of extra certificate authorities to trust
+	CACert string `yaml:"cacert,omitempty"`
+	// Cert and Key are a PEM client certificate and its private key
+	Cert string `yaml:"cert,omitempty"`
+	Key  string `yaml:"key,omitempty"`
+	// Insecure skips verification of the server certificate
+	Insecure bool `yaml:"insecure,omitempty"`
+}
+
+// IsZero reports whether no option is set
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+// Merge returns o with every option that is set in override replaced
+func (o Options) Merge(override Options) Options {
+	if override.Timeout != 0 {
+		o.Timeout = override.Timeout
+	}
+	if override.ConnectTimeout != 0 {
+		o.ConnectTimeout = override.ConnectTimeout
+	}
+	if override.Proxy != "" {
+		o.Proxy = override.Proxy
+	}
+	if override.CACert != "" {
+		o.CACert = override.CACert
+	}
+	if override.Cert != "" {
+		o.Cert = override.Cert
+	}
+	if override.Key != "" {
+		o.Key = override.Key
+	}
+	if override.Insecure {
+		o.Insecure = true
+	}
+	return o
+}
+
+// NewClient creates an HTTP client using a transport built from options
+func NewClient(options Options) (*http.Client, error) {
+	transport, err := NewTransport(options)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// NewTransport creates an HTTP transport with the proxy, TLS and connect
+// timeout settings from options
+func NewTransport(options Options) (*http.Transport, error) {
+	connectTimeout := options.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+
+	if options.Proxy != "" {
+		proxyURL, err := parseProxy(options.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// parseProxy validates a proxy URL, defaulting to http:// when no scheme is given
+func parseProxy(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, xurlErrors.NewHTTPError(fmt.Errorf("invalid proxy %q: %v", proxy, err))
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, xurlErrors.NewHTTPError(fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", proxyURL.Scheme))
+	}
+	if proxyURL.Host == "" {
+		return nil, xurlErrors.NewHTTPError(fmt.Errorf("invalid proxy %q: missing host", proxy))
+	}
+	return proxyURL, nil
+}
+
+// newTLSConfig builds the TLS configuration for custom CAs and client certificates
+func newTLSConfig(options Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: options.Insecure,
+	}
+
+	if options.CACert != "" {
+		pem, err := os.ReadFile(options.CACert)
+		if err != nil {
+			return nil, xurlErrors.NewIOError(fmt.Errorf("error reading CA certificate: %v", err))
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, xurlErrors.NewIOError(fmt.Errorf("no certificates found in %s", options.CACert))
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if options.Cert != "" || options.Key != "" {
+		if options.Cert == "" || options.Key == "" {
+			return nil, xurlErrors.NewIOError(fmt.Errorf("a client certificate needs both --cert and --key"))
+		}
+		cert, err := tls.LoadX509KeyPair(options.Cert, options.Key)
+		if err != nil {
+			return nil, xurlErrors.NewIOError(fmt.Errorf("error loading client certificate: %v", err))
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
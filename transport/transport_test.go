@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	base := Options{Timeout: time.Minute, Proxy: "http://proxy:3128", CACert: "ca.pem"}
+	merged := base.Merge(Options{Proxy: "socks5://127.0.0.1:1080", Insecure: true})
+
+	assert.Equal(t, time.Minute, merged.Timeout, "Unset options should be kept")
+	assert.Equal(t, "socks5://127.0.0.1:1080", merged.Proxy)
+	assert.Equal(t, "ca.pem", merged.CACert)
+	assert.True(t, merged.Insecure)
+	assert.True(t, Options{}.IsZero())
+	assert.False(t, merged.IsZero())
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		client, err := NewClient(Options{})
+		require.NoError(t, err)
+		assert.Equal(t, DefaultTimeout, client.Timeout)
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		transport, err := NewTransport(Options{Proxy: "127.0.0.1:3128"})
+		require.NoError(t, err)
+		req, _ := http.NewRequest("GET", "https://api.x.com/2/users/me", nil)
+		proxyURL, err := transport.Proxy(req)
+		require.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:3128", proxyURL.String(), "Scheme should default to http")
+
+		_, err = NewTransport(Options{Proxy: "ftp://proxy"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Client certificate needs a key", func(t *testing.T) {
+		_, err := NewClient(Options{Cert: "client.pem"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid CA file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0600))
+		_, err := NewClient(Options{CACert: path})
+		assert.Error(t, err)
+	})
+}
+
+func TestCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	t.Run("Untrusted server fails", func(t *testing.T) {
+		client, err := NewClient(Options{})
+		require.NoError(t, err)
+		_, err = client.Get(server.URL)
+		assert.Error(t, err)
+	})
+
+	t.Run("Trusted with --cacert", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, os.WriteFile(path, certPEM, 0600))
+
+		client, err := NewClient(Options{CACert: path})
+		require.NoError(t, err)
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Insecure skips verification", func(t *testing.T) {
+		client, err := NewClient(Options{Insecure: true})
+		require.NoError(t, err)
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})
+}
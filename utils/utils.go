@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/fatih/color"
@@ -15,8 +16,8 @@ var boolColor = color.New(color.FgMagenta)
 var nullColor = color.New(color.FgRed)
 var structureColor = color.New(color.FgWhite, color.Bold)
 
-// colorizeAndPrintJSON prints JSON with syntax highlighting
-func colorizeAndPrintJSON(jsonStr string) {
+// colorizeAndPrintJSON writes JSON to w with syntax highlighting
+func colorizeAndPrintJSON(w io.Writer, jsonStr string) {
 	lines := strings.Split(jsonStr, "\n")
 	for _, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
@@ -25,7 +26,7 @@ func colorizeAndPrintJSON(jsonStr string) {
 			trimmedLine == "[" || trimmedLine == "]" ||
 			trimmedLine == "," || trimmedLine == "}," ||
 			trimmedLine == "]," {
-			structureColor.Println(line)
+			structureColor.Fprintln(w, line)
 			continue
 		}
 
@@ -34,15 +35,15 @@ func colorizeAndPrintJSON(jsonStr string) {
 			key := parts[0]
 			value := strings.TrimSpace(parts[1])
 
-			keyColor.Print(key)
-			fmt.Print(":")
+			keyColor.Fprint(w, key)
+			fmt.Fprint(w, ":")
 
 			if strings.HasSuffix(value, "{") || strings.HasSuffix(value, "[") {
 				valueWithoutBracket := strings.TrimSuffix(strings.TrimSuffix(value, "{"), "[")
 				if valueWithoutBracket != "" {
-					fmt.Print(valueWithoutBracket)
+					fmt.Fprint(w, valueWithoutBracket)
 				}
-				structureColor.Println(value[len(valueWithoutBracket):])
+				structureColor.Fprintln(w, value[len(valueWithoutBracket):])
 				continue
 			}
 
@@ -59,46 +60,51 @@ func colorizeAndPrintJSON(jsonStr string) {
 					valueBeforeBracket := value[:lastBracketPos]
 					bracketPart := value[lastBracketPos:]
 
-					colorizeValue(valueBeforeBracket)
-					structureColor.Println(bracketPart)
+					colorizeValue(w, valueBeforeBracket)
+					structureColor.Fprintln(w, bracketPart)
 				} else {
-					structureColor.Println(value)
+					structureColor.Fprintln(w, value)
 				}
 				continue
 			}
 
-			colorizeValue(value)
+			colorizeValue(w, value)
 		} else {
-			colorizeValue(line)
+			colorizeValue(w, line)
 		}
 	}
 }
 
 // Helper function to colorize values based on their type
-func colorizeValue(value string) {
+func colorizeValue(w io.Writer, value string) {
 	trimmedValue := strings.TrimSpace(value)
 
 	switch {
 	case strings.HasPrefix(trimmedValue, "\"") && (strings.HasSuffix(trimmedValue, "\"") || strings.HasSuffix(trimmedValue, "\",")):
-		stringColor.Println(value)
+		stringColor.Fprintln(w, value)
 	case trimmedValue == "true" || trimmedValue == "false" ||
 		strings.HasSuffix(trimmedValue, "true,") || strings.HasSuffix(trimmedValue, "false,"):
-		boolColor.Println(value)
+		boolColor.Fprintln(w, value)
 	case trimmedValue == "null" || strings.HasSuffix(trimmedValue, "null,"):
-		nullColor.Println(value)
+		nullColor.Fprintln(w, value)
 	case strings.HasPrefix(trimmedValue, "{") || strings.HasPrefix(trimmedValue, "["):
-		structureColor.Println(value)
+		structureColor.Fprintln(w, value)
 	default:
-		numberColor.Println(value)
+		numberColor.Fprintln(w, value)
 	}
 }
 
 func FormatAndPrintResponse(response any) error {
+	return FormatResponse(color.Output, response)
+}
+
+// FormatResponse writes response to w as indented, highlighted JSON
+func FormatResponse(w io.Writer, response any) error {
 	prettyJSON, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error formatting JSON: %v", err)
 	}
 
-	colorizeAndPrintJSON(string(prettyJSON))
+	colorizeAndPrintJSON(w, string(prettyJSON))
 	return nil
 }